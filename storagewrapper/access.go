@@ -0,0 +1,84 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"cloud.google.com/go/storage"
+)
+
+// Permission a caller needs to read objects.
+const readPermission = "storage.objects.get"
+
+// bucketAttrs returns the attributes of bucket, fetching them on first use.
+func (t *threadData) bucketAttrs(ctx context.Context, bucket string) (*storage.BucketAttrs, error) {
+	t.bucketAttrsMu.Lock()
+	defer t.bucketAttrsMu.Unlock()
+	if attrs, ok := t.bucketAttrsCache[bucket]; ok {
+		return attrs, nil
+	}
+	attrs, err := t.bucket(bucket).Attrs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting bucket attrs: %w", err)
+	}
+	if t.bucketAttrsCache == nil {
+		t.bucketAttrsCache = make(map[string]*storage.BucketAttrs)
+	}
+	t.bucketAttrsCache[bucket] = attrs
+	return attrs, nil
+}
+
+// checkReadAccess returns an error if the caller can't be confirmed to have
+// read access to oh. With uniform bucket-level access, only bucket IAM is
+// checked. Otherwise, the object ACL is listed first, which succeeds only for
+// callers with owner access to the object, falling back to bucket IAM.
+func (t *threadData) checkReadAccess(ctx context.Context, oh *storage.ObjectHandle) error {
+	attrs, err := t.bucketAttrs(ctx, oh.BucketName())
+	if err != nil {
+		return err
+	}
+	if !attrs.UniformBucketLevelAccess.Enabled {
+		if _, err := oh.ACL().List(ctx); err == nil {
+			return nil
+		}
+	}
+	granted, err := t.bucket(oh.BucketName()).IAM().TestPermissions(ctx, []string{readPermission})
+	if err != nil {
+		return fmt.Errorf("testing bucket permissions: %w", err)
+	}
+	if !slices.Contains(granted, readPermission) {
+		return fmt.Errorf("caller lacks %s on bucket %s: %w", readPermission, oh.BucketName(), errPermissionDenied)
+	}
+	return nil
+}
+
+// GoStorageSetACLCheck makes opening a read file on td first confirm the
+// caller has read access, failing the open with a permission error instead of
+// at the first read. Bucket attributes are cached per thread, so each bucket
+// costs one extra lookup. Returns 0 on success and a negative error code on
+// error.
+//
+//export GoStorageSetACLCheck
+func GoStorageSetACLCheck(td uintptr, enabled bool) int {
+	slog.Debug("go storage set acl check",
+		"td", td,
+		"enabled", enabled,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set acl check: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	t.aclCheck = enabled
+	return 0
+}
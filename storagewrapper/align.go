@@ -0,0 +1,95 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"log/slog"
+	"math/bits"
+	"unsafe"
+)
+
+// checkAlignment returns an error if t requires aligned I/O and offset or
+// buffer b isn't aligned.
+func (t *threadData) checkAlignment(offset int64, b unsafe.Pointer) error {
+	align := t.alignment.Load()
+	if align <= 0 {
+		return nil
+	}
+	if offset%align != 0 || int64(uintptr(b))%align != 0 {
+		return fmt.Errorf("offset %d or buffer %p not aligned to %d bytes: %w", offset, b, align, errInvalidArgument)
+	}
+	return nil
+}
+
+// GoStorageSetAlignmentRequirement makes GoStorageQueue, and the other calls
+// that queue reads, reject reads on files opened on td whose offset or buffer
+// isn't aligned to alignBytes, a power of two, to catch misaligned I/O in
+// O_DIRECT benchmarks. An alignBytes of 0 removes the requirement. Returns 0
+// on success and a negative error code on error.
+//
+//export GoStorageSetAlignmentRequirement
+func GoStorageSetAlignmentRequirement(td uintptr, alignBytes C.int) int {
+	slog.Debug("go storage set alignment requirement",
+		"td", td,
+		"align_bytes", alignBytes,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set alignment requirement: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if alignBytes < 0 || bits.OnesCount(uint(alignBytes)) > 1 {
+		slog.Error("set alignment requirement: alignment must be a power of two", "align_bytes", alignBytes)
+		return int(codeInvalidArgument)
+	}
+	t.alignment.Store(int64(alignBytes))
+	return 0
+}
+
+// GoStorageAllocAlignedBuffer allocates sizeBytes of memory aligned to
+// alignBytes, a power of two that is a multiple of the pointer size, for
+// reads that must meet GoStorageSetAlignmentRequirement. The buffer must be
+// released with GoStorageFreeAlignedBuffer. Returns NULL on error.
+//
+//export GoStorageAllocAlignedBuffer
+func GoStorageAllocAlignedBuffer(sizeBytes int64, alignBytes C.int) unsafe.Pointer {
+	slog.Debug("go storage alloc aligned buffer",
+		"size_bytes", sizeBytes,
+		"align_bytes", alignBytes,
+	)
+	if sizeBytes <= 0 {
+		slog.Error("alloc aligned buffer: invalid size", "size_bytes", sizeBytes)
+		return nil
+	}
+	if alignBytes <= 0 || bits.OnesCount(uint(alignBytes)) != 1 || int(alignBytes)%int(unsafe.Sizeof(uintptr(0))) != 0 {
+		slog.Error("alloc aligned buffer: alignment must be a power of two multiple of the pointer size", "align_bytes", alignBytes)
+		return nil
+	}
+	p := allocAligned(int(sizeBytes), int(alignBytes))
+	if p == nil {
+		slog.Error("alloc aligned buffer: allocation failed",
+			"size_bytes", sizeBytes,
+			"align_bytes", alignBytes,
+		)
+	}
+	return p
+}
+
+// GoStorageFreeAlignedBuffer frees buf, allocated by
+// GoStorageAllocAlignedBuffer. buf may be NULL.
+//
+//export GoStorageFreeAlignedBuffer
+func GoStorageFreeAlignedBuffer(buf unsafe.Pointer) {
+	slog.Debug("go storage free aligned buffer")
+	C.free(buf)
+}
@@ -0,0 +1,31 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+// This file holds the C side of align.go. Files with exports may only declare
+// C functions, so it has none.
+
+/*
+#include <stdlib.h>
+
+static void* alloc_aligned(size_t size, size_t alignment) {
+	void* p = NULL;
+	if (posix_memalign(&p, alignment, size) != 0) {
+		return NULL;
+	}
+	return p;
+}
+*/
+import "C"
+
+import "unsafe"
+
+// allocAligned returns size bytes of C memory aligned to alignment, which must
+// be a power of two multiple of the pointer size, or nil if allocation fails.
+func allocAligned(size, alignment int) unsafe.Pointer {
+	return C.alloc_aligned(C.size_t(size), C.size_t(alignment))
+}
@@ -0,0 +1,420 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+/*
+#include <stdint.h>
+
+// Object attributes filled in by GoStorageStat2. Times are in seconds since
+// the Unix epoch, and content_type is NUL-terminated, truncated if necessary.
+typedef struct {
+	int64_t size;
+	int64_t generation;
+	int64_t created_unix;
+	int64_t updated_unix;
+	char content_type[256];
+} GoStorageObjectAttrs;
+*/
+import "C"
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"cloud.google.com/go/storage"
+	"golang.org/x/time/rate"
+)
+
+// How long object attributes stay cached unless changed with
+// GoStorageSetCacheTTL, and the TTL that keeps them cached forever.
+const (
+	defaultAttrsCacheTTL = 60 * time.Second
+	attrsCacheForever    = time.Duration(-1)
+)
+
+// objectInfo tracks the object backing a read file, along with its attributes
+// once they are known.
+type objectInfo struct {
+	oh *storage.ObjectHandle
+
+	// The cached attributes and when they were cached, which expire after the
+	// TTL in cacheTTL.
+	attrsMu        sync.Mutex
+	attrs          *storage.ObjectAttrs
+	cacheEntryTime time.Time
+	cacheTTL       *atomic.Int64
+	// The generation in the first attributes cached, which doesn't expire.
+	generation int64
+
+	// If non-nil, called on close to stop counting the file against its
+	// thread's bucket limit.
+	releaseBucket func()
+
+	// If non-nil, limits the file's read bandwidth.
+	limiterMu sync.Mutex
+	limiter   *rate.Limiter
+}
+
+// infoFile is implemented by files that embed an objectInfo.
+type infoFile interface {
+	info() *objectInfo
+}
+
+func (o *objectInfo) info() *objectInfo {
+	return o
+}
+
+// newObjectInfo returns an objectInfo for oh that caches attributes for t's
+// cache TTL.
+func newObjectInfo(t *threadData, oh *storage.ObjectHandle) objectInfo {
+	return objectInfo{oh: oh, cacheTTL: &t.attrsCacheTTL}
+}
+
+// cachedAttrs returns the cached attributes, or nil if they haven't been
+// fetched or have expired. Expired attributes are evicted.
+func (o *objectInfo) cachedAttrs() *storage.ObjectAttrs {
+	o.attrsMu.Lock()
+	defer o.attrsMu.Unlock()
+	if o.attrs == nil {
+		return nil
+	}
+	if ttl := time.Duration(o.cacheTTL.Load()); ttl != attrsCacheForever && time.Since(o.cacheEntryTime) > ttl {
+		o.attrs = nil
+		return nil
+	}
+	return o.attrs
+}
+
+// cacheAttrs caches attrs as the object's attributes.
+func (o *objectInfo) cacheAttrs(attrs *storage.ObjectAttrs) {
+	o.attrsMu.Lock()
+	defer o.attrsMu.Unlock()
+	o.attrs = attrs
+	o.cacheEntryTime = time.Now()
+	if o.generation == 0 {
+		o.generation = attrs.Generation
+	}
+}
+
+// pinnedGeneration returns the generation in the first attributes cached, or 0
+// if none have been.
+func (o *objectInfo) pinnedGeneration() int64 {
+	o.attrsMu.Lock()
+	defer o.attrsMu.Unlock()
+	return o.generation
+}
+
+// fetchAttrs fetches the object's attributes and caches them.
+func (o *objectInfo) fetchAttrs() (*storage.ObjectAttrs, error) {
+	attrs, err := o.oh.Attrs(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("fetching attrs for %v/%v: %w", o.oh.BucketName(), o.oh.ObjectName(), err)
+	}
+	o.cacheAttrs(attrs)
+	return attrs, nil
+}
+
+// loadAttrs returns the cached attributes, fetching them if they haven't been
+// fetched or have expired.
+func (o *objectInfo) loadAttrs() (*storage.ObjectAttrs, error) {
+	if attrs := o.cachedAttrs(); attrs != nil {
+		return attrs, nil
+	}
+	return o.fetchAttrs()
+}
+
+// readerObjectAttrs converts the attributes returned when opening a reader
+// into a partially populated storage.ObjectAttrs.
+func readerObjectAttrs(oh *storage.ObjectHandle, ra storage.ReaderObjectAttrs) *storage.ObjectAttrs {
+	return &storage.ObjectAttrs{
+		Bucket:          oh.BucketName(),
+		Name:            oh.ObjectName(),
+		Size:            ra.Size,
+		ContentType:     ra.ContentType,
+		ContentEncoding: ra.ContentEncoding,
+		CacheControl:    ra.CacheControl,
+		Updated:         ra.LastModified,
+		Generation:      ra.Generation,
+		Metageneration:  ra.Metageneration,
+		CRC32C:          ra.CRC32C,
+	}
+}
+
+// GoStorageFetchAttributes fetches and caches the attributes of the object
+// backing read file v. It is safe to call concurrently with reads, so callers
+// may open with O_DIRECT (which skips the metadata round trip) and fetch
+// attributes in the background. Returns 0 on success and a negative error code
+// on error.
+//
+//export GoStorageFetchAttributes
+func GoStorageFetchAttributes(v uintptr) int {
+	slog.Debug("go storage fetch attributes", "handle", v)
+	f, _, ok := handle[infoFile](v)
+	if !ok {
+		slog.Error("fetch attributes: wrong type handle", "v", v)
+		return int(codeBadHandle)
+	}
+	if _, err := f.info().fetchAttrs(); err != nil {
+		slog.Error("fetch attributes failed", "err", err)
+		return int(errorCodeOf(err))
+	}
+	return 0
+}
+
+// GoStorageObjectSize returns the size of the object backing read file v,
+// fetching its attributes first if they aren't cached. Returns a negative
+// error code on error.
+//
+//export GoStorageObjectSize
+func GoStorageObjectSize(v uintptr) int64 {
+	slog.Debug("go storage object size", "handle", v)
+	f, _, ok := handle[infoFile](v)
+	if !ok {
+		slog.Error("object size: wrong type handle", "v", v)
+		return int64(codeBadHandle)
+	}
+	attrs, err := f.info().loadAttrs()
+	if err != nil {
+		slog.Error("object size: fetch attributes failed", "err", err)
+		return int64(errorCodeOf(err))
+	}
+	return attrs.Size
+}
+
+// GoStorageGetObjectGeneration returns the generation of the object backing
+// read file v, or a negative value if v is invalid or its attributes haven't
+// been fetched.
+// The generation reflects the object when it was opened (or when attributes
+// were first fetched); it is not updated if the object is later overwritten,
+// nor does it expire from the attribute cache.
+//
+//export GoStorageGetObjectGeneration
+func GoStorageGetObjectGeneration(v uintptr) int64 {
+	slog.Debug("go storage get object generation", "handle", v)
+	f, _, ok := handle[infoFile](v)
+	if !ok {
+		slog.Error("get object generation: wrong type handle", "v", v)
+		return int64(codeBadHandle)
+	}
+	generation := f.info().pinnedGeneration()
+	if generation == 0 {
+		return -1
+	}
+	return generation
+}
+
+// cachedAttrString writes the NUL-terminated attribute that field selects from
+// read file v's cached attributes into buf, for accessors named by op. Returns
+// the length written, excluding the terminator, 0 if the attribute isn't
+// cached or has expired, and a negative error code on error, including if buf
+// is too small.
+func cachedAttrString(op string, v uintptr, buf *C.char, bufLen C.int, field func(*storage.ObjectAttrs) string) int {
+	f, _, ok := handle[infoFile](v)
+	if !ok {
+		slog.Error(op+": wrong type handle", "v", v)
+		return int(codeBadHandle)
+	}
+	attrs := f.info().cachedAttrs()
+	if attrs == nil || field(attrs) == "" {
+		return 0
+	}
+	n := writeCString(buf, bufLen, field(attrs))
+	if n < 0 {
+		slog.Error(op+": buffer too small", "buf_len", bufLen)
+	}
+	return n
+}
+
+// GoStorageObjectGetContentType writes the NUL-terminated content type of the
+// object backing read file v into buf, from its cached attributes, without a
+// network call. Files opened without O_DIRECT know it from the open; others
+// need GoStorageFetchAttributes or GoStorageStatHandle first, as do files
+// whose attributes have expired under GoStorageSetCacheTTL. Returns the length
+// written, excluding the terminator, 0 if it isn't known, and a negative error
+// code on error, including if buf is too small.
+//
+//export GoStorageObjectGetContentType
+func GoStorageObjectGetContentType(v uintptr, buf *C.char, bufLen C.int) int {
+	slog.Debug("go storage object get content type", "handle", v)
+	return cachedAttrString("object get content type", v, buf, bufLen, func(a *storage.ObjectAttrs) string {
+		return a.ContentType
+	})
+}
+
+// GoStorageObjectGetStorageClass writes the NUL-terminated storage class of the
+// object backing read file v, e.g. "STANDARD", into buf, from its cached
+// attributes, without a network call. Only files that read with range readers
+// learn it when opened; for others, or once the attributes have expired under
+// GoStorageSetCacheTTL, call GoStorageFetchAttributes first. Returns the length
+// written, excluding the terminator, 0 if it isn't known, and a negative error
+// code on error, including if buf is too small.
+//
+//export GoStorageObjectGetStorageClass
+func GoStorageObjectGetStorageClass(v uintptr, buf *C.char, bufLen C.int) int {
+	slog.Debug("go storage object get storage class", "handle", v)
+	return cachedAttrString("object get storage class", v, buf, bufLen, func(a *storage.ObjectAttrs) string {
+		return a.StorageClass
+	})
+}
+
+// GoStorageStatHandle fetches and caches the attributes of the object backing
+// read file v, unless they are already cached, e.g. from opening without
+// O_DIRECT. Returns 0 on success and a negative error code on error.
+//
+//export GoStorageStatHandle
+func GoStorageStatHandle(v uintptr) int {
+	slog.Debug("go storage stat handle", "handle", v)
+	f, _, ok := handle[infoFile](v)
+	if !ok {
+		slog.Error("stat handle: wrong type handle", "v", v)
+		return int(codeBadHandle)
+	}
+	if _, err := f.info().loadAttrs(); err != nil {
+		slog.Error("stat handle: fetch attributes failed", "err", err)
+		return int(errorCodeOf(err))
+	}
+	return 0
+}
+
+// GoStorageSetCacheTTL sets how long files opened on td keep object
+// attributes cached, including files that are already open. Once attributes
+// expire, calls that need them fetch them again. A ttlSeconds of 0 disables
+// caching, so every such call fetches attributes, and -1 caches them forever.
+// The default is 60 seconds. Returns 0 on success and a negative error code on
+// error.
+//
+//export GoStorageSetCacheTTL
+func GoStorageSetCacheTTL(td uintptr, ttlSeconds C.int) int {
+	slog.Debug("go storage set cache ttl",
+		"td", td,
+		"ttl_seconds", ttlSeconds,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set cache ttl: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	ttl := time.Duration(ttlSeconds) * time.Second
+	if ttlSeconds == -1 {
+		ttl = attrsCacheForever
+	} else if ttlSeconds < 0 {
+		slog.Error("set cache ttl: invalid ttl", "ttl_seconds", ttlSeconds)
+		return int(codeInvalidArgument)
+	}
+	t.attrsCacheTTL.Store(int64(ttl))
+	return 0
+}
+
+// GoStorageStat2 fetches the attributes of an object into attrs, without
+// opening it. Returns 0 on success and a negative error code on error.
+//
+//export GoStorageStat2
+func GoStorageStat2(td uintptr, filenameCstr *C.char, attrs *C.GoStorageObjectAttrs) int {
+	filename := C.GoString(filenameCstr)
+	slog.Debug("go storage stat2",
+		"td", td,
+		"filename", filename,
+	)
+	if attrs == nil {
+		slog.Error("stat2: NULL attrs")
+		return int(codeInvalidArgument)
+	}
+	_, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("stat2: error getting *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	a, err := oh.Attrs(context.Background())
+	if err != nil {
+		slog.Error("stat2: failed to get object attrs",
+			"filename", filename,
+			"err", err,
+		)
+		return int(errorCodeOf(err))
+	}
+	attrs.size = C.int64_t(a.Size)
+	attrs.generation = C.int64_t(a.Generation)
+	attrs.created_unix = C.int64_t(a.Created.Unix())
+	attrs.updated_unix = C.int64_t(a.Updated.Unix())
+	contentType := unsafe.Slice((*byte)(unsafe.Pointer(&attrs.content_type[0])), len(attrs.content_type))
+	n := copy(contentType[:len(contentType)-1], a.ContentType)
+	contentType[n] = 0
+	return 0
+}
+
+// GoStorageObjectGetCRC32C writes the CRC32C checksum GCS stores for an
+// object to *crc32c, so that it can be checked after a write without reading
+// the object back. Returns 0 on success and a negative error code on error.
+//
+//export GoStorageObjectGetCRC32C
+func GoStorageObjectGetCRC32C(td uintptr, filenameCstr *C.char, crc32c *C.uint32_t) int {
+	filename := C.GoString(filenameCstr)
+	slog.Debug("go storage object get crc32c",
+		"td", td,
+		"filename", filename,
+	)
+	if crc32c == nil {
+		slog.Error("object get crc32c: NULL crc32c")
+		return int(codeInvalidArgument)
+	}
+	_, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("object get crc32c: error getting *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	a, err := oh.Attrs(context.Background())
+	if err != nil {
+		slog.Error("object get crc32c: failed to get object attrs",
+			"filename", filename,
+			"err", err,
+		)
+		return int(errorCodeOf(err))
+	}
+	*crc32c = C.uint32_t(a.CRC32C)
+	return 0
+}
+
+// GoStorageObjectGetMD5 writes the base64 encoded MD5 hash GCS stores for an
+// object to md5Buf, NUL-terminated. Composite objects have no MD5 hash, so an
+// empty string is written for them. Returns the number of bytes written,
+// excluding the terminator, or a negative error code on error.
+//
+//export GoStorageObjectGetMD5
+func GoStorageObjectGetMD5(td uintptr, filenameCstr, md5Buf *C.char, md5BufLen C.int) int {
+	filename := C.GoString(filenameCstr)
+	slog.Debug("go storage object get md5",
+		"td", td,
+		"filename", filename,
+	)
+	_, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("object get md5: error getting *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	a, err := oh.Attrs(context.Background())
+	if err != nil {
+		slog.Error("object get md5: failed to get object attrs",
+			"filename", filename,
+			"err", err,
+		)
+		return int(errorCodeOf(err))
+	}
+	n := writeCString(md5Buf, md5BufLen, base64.StdEncoding.EncodeToString(a.MD5))
+	if n < 0 {
+		slog.Error("object get md5: buffer too small", "buf_len", md5BufLen)
+	}
+	return n
+}
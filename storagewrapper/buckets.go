@@ -0,0 +1,343 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// bucketStatsEntry counts the reads a thread has made from one bucket.
+type bucketStatsEntry struct {
+	totalBytesRead atomic.Int64
+	totalReads     atomic.Int64
+	totalErrors    atomic.Int64
+}
+
+// bucketStatsJSON is the JSON form of a bucketStatsEntry.
+type bucketStatsJSON struct {
+	BytesRead int64 `json:"bytesRead"`
+	Reads     int64 `json:"reads"`
+	Errors    int64 `json:"errors"`
+}
+
+// recordRead counts the completed read op, which failed if err is non-nil,
+// against its bucket. Operations other than reads are ignored.
+func (t *threadData) recordRead(op inflightIO, err error) {
+	if op.bucket == "" {
+		return
+	}
+	t.bucketStatsMu.RLock()
+	e := t.bucketStats[op.bucket]
+	t.bucketStatsMu.RUnlock()
+	if e == nil {
+		t.bucketStatsMu.Lock()
+		if e = t.bucketStats[op.bucket]; e == nil {
+			if t.bucketStats == nil {
+				t.bucketStats = make(map[string]*bucketStatsEntry)
+			}
+			e = &bucketStatsEntry{}
+			t.bucketStats[op.bucket] = e
+		}
+		t.bucketStatsMu.Unlock()
+	}
+	e.totalReads.Add(1)
+	if err != nil {
+		e.totalErrors.Add(1)
+		return
+	}
+	e.totalBytesRead.Add(op.n)
+}
+
+// bucketLocation describes where a bucket's data is stored.
+type bucketLocation struct {
+	Location     string `json:"location"`
+	LocationType string `json:"locationType"`
+	StorageClass string `json:"storageClass"`
+}
+
+// acquireBucket counts a file open in bucket against t's bucket limit,
+// returning a function that releases it, or an error if the file would take t
+// over the limit. Returns a nil release function if there is no limit.
+func (t *threadData) acquireBucket(bucket string) (func(), error) {
+	t.bucketsMu.Lock()
+	defer t.bucketsMu.Unlock()
+	if t.maxBuckets <= 0 {
+		return nil, nil
+	}
+	if t.activeBuckets[bucket] == 0 && len(t.activeBuckets) >= t.maxBuckets {
+		return nil, fmt.Errorf("too many buckets: %d already have open files: %w", len(t.activeBuckets), errInvalidArgument)
+	}
+	if t.activeBuckets == nil {
+		t.activeBuckets = make(map[string]int)
+	}
+	t.activeBuckets[bucket]++
+	return func() {
+		t.bucketsMu.Lock()
+		defer t.bucketsMu.Unlock()
+		if t.activeBuckets[bucket]--; t.activeBuckets[bucket] <= 0 {
+			delete(t.activeBuckets, bucket)
+		}
+	}, nil
+}
+
+// GoStorageSetMaxConcurrentBuckets limits the read files open on td at once,
+// via GoStorageOpenReadonly or GoStorageOpenRaw, to objects in at most
+// maxBuckets distinct buckets. Opening a file in another bucket fails until
+// every file in one of them is closed. A maxBuckets of 0 removes the limit;
+// files opened while there is no limit aren't counted. Returns 0 on success
+// and a negative error code on error.
+//
+//export GoStorageSetMaxConcurrentBuckets
+func GoStorageSetMaxConcurrentBuckets(td uintptr, maxBuckets C.int) int {
+	slog.Debug("go storage set max concurrent buckets",
+		"td", td,
+		"max_buckets", maxBuckets,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set max concurrent buckets: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if maxBuckets < 0 {
+		slog.Error("set max concurrent buckets: negative max", "max_buckets", maxBuckets)
+		return int(codeInvalidArgument)
+	}
+	t.bucketsMu.Lock()
+	defer t.bucketsMu.Unlock()
+	t.maxBuckets = int(maxBuckets)
+	return 0
+}
+
+// GoStorageGetBucketLocation writes a NUL-terminated JSON description of where
+// bucket stores its data into buf, e.g. {"location":"US-EAST1",
+// "locationType":"region","storageClass":"STANDARD"}, so that benchmarks can
+// confirm they run near their data. Bucket attributes are cached per thread.
+// Returns the number of bytes written, excluding the terminator, or a negative
+// error code on error, including if buf is too small.
+//
+//export GoStorageGetBucketLocation
+func GoStorageGetBucketLocation(td uintptr, bucketCstr, buf *C.char, bufLen C.int) int {
+	bucket := C.GoString(bucketCstr)
+	slog.Debug("go storage get bucket location",
+		"td", td,
+		"bucket", bucket,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("get bucket location: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	attrs, err := t.bucketAttrs(context.Background(), bucket)
+	if err != nil {
+		slog.Error("get bucket location: failed to get bucket attrs",
+			"bucket", bucket,
+			"err", err,
+		)
+		return int(errorCodeOf(err))
+	}
+	b, err := json.Marshal(bucketLocation{
+		Location:     attrs.Location,
+		LocationType: attrs.LocationType,
+		StorageClass: attrs.StorageClass,
+	})
+	if err != nil {
+		slog.Error("get bucket location: marshal failed", "err", err)
+		return int(codeUnknown)
+	}
+	n := writeCString(buf, bufLen, string(b))
+	if n < 0 {
+		slog.Error("get bucket location: buffer too small", "buf_len", bufLen)
+	}
+	return n
+}
+
+// GoStorageSetProjectID sets the project that GoStorageSetupBucket creates
+// buckets in. Returns 0 on success and a negative error code on error.
+//
+//export GoStorageSetProjectID
+func GoStorageSetProjectID(td uintptr, projectIDCstr *C.char) int {
+	projectID := C.GoString(projectIDCstr)
+	slog.Debug("go storage set project id",
+		"td", td,
+		"project_id", projectID,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set project id: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	t.projectID = projectID
+	return 0
+}
+
+// GoStorageSetDefaultBucket sets the bucket of filenames that are bare object
+// names, with no "bucket/" prefix, when all of a job's objects are in one
+// bucket. Filenames with a prefix still name their own bucket. An empty bucket
+// removes the default, so that bare names are rejected again. Returns 0 on
+// success and a negative error code on error.
+//
+//export GoStorageSetDefaultBucket
+func GoStorageSetDefaultBucket(td uintptr, bucketCstr *C.char) int {
+	bucket := C.GoString(bucketCstr)
+	slog.Debug("go storage set default bucket",
+		"td", td,
+		"bucket", bucket,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set default bucket: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if strings.Contains(bucket, "/") {
+		slog.Error("set default bucket: invalid bucket name", "bucket", bucket)
+		return int(codeInvalidArgument)
+	}
+	t.defaultBucket = bucket
+	return 0
+}
+
+// bucketConfig is the JSON configuration accepted by GoStorageSetupBucket.
+type bucketConfig struct {
+	Location                 string `json:"location"`
+	StorageClass             string `json:"storageClass"`
+	UniformBucketLevelAccess bool   `json:"uniformBucketLevelAccess"`
+	RetentionPolicySeconds   int64  `json:"retentionPolicySeconds"`
+}
+
+// isAlreadyExists reports whether err means the resource being created
+// already exists.
+func isAlreadyExists(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict {
+		return true
+	}
+	return status.Code(err) == codes.AlreadyExists
+}
+
+// GoStorageSetupBucket creates bucket in the project set with
+// GoStorageSetProjectID, configured by configJSON, e.g.
+// {"location":"US-EAST1","storageClass":"STANDARD",
+// "uniformBucketLevelAccess":true,"retentionPolicySeconds":0}. Omitted fields
+// take the service defaults, and a retention period of 0 sets no retention
+// policy. An existing bucket is left as is. Returns 0 if the bucket was
+// created, 1 if it already exists, and a negative error code on error.
+//
+//export GoStorageSetupBucket
+func GoStorageSetupBucket(td uintptr, bucketCstr, configJSONCstr *C.char) int {
+	bucket := C.GoString(bucketCstr)
+	configJSON := C.GoString(configJSONCstr)
+	slog.Info("go storage setup bucket",
+		"td", td,
+		"bucket", bucket,
+		"config", configJSON,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("setup bucket: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if t.projectID == "" {
+		slog.Error("setup bucket: no project id set")
+		return int(codeInvalidArgument)
+	}
+	var cfg bucketConfig
+	if configJSON != "" {
+		if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+			slog.Error("setup bucket: invalid config", "err", err)
+			return int(codeInvalidArgument)
+		}
+	}
+	if cfg.RetentionPolicySeconds < 0 {
+		slog.Error("setup bucket: negative retention period",
+			"retention_policy_seconds", cfg.RetentionPolicySeconds,
+		)
+		return int(codeInvalidArgument)
+	}
+
+	attrs := &storage.BucketAttrs{
+		Location:     cfg.Location,
+		StorageClass: cfg.StorageClass,
+		UniformBucketLevelAccess: storage.UniformBucketLevelAccess{
+			Enabled: cfg.UniformBucketLevelAccess,
+		},
+	}
+	if cfg.RetentionPolicySeconds > 0 {
+		attrs.RetentionPolicy = &storage.RetentionPolicy{
+			RetentionPeriod: time.Duration(cfg.RetentionPolicySeconds) * time.Second,
+		}
+	}
+	err := t.bucket(bucket).Create(context.Background(), t.projectID, attrs)
+	if isAlreadyExists(err) {
+		slog.Info("setup bucket: bucket already exists", "bucket", bucket)
+		return 1
+	}
+	if err != nil {
+		slog.Error("setup bucket: failed to create bucket",
+			"bucket", bucket,
+			"err", err,
+		)
+		return int(errorCodeOf(err))
+	}
+	return 0
+}
+
+// GoStorageGetBucketStats writes a NUL-terminated JSON summary of the reads td
+// has completed from bucket into buf, e.g.
+// {"bytesRead":1048576,"reads":16,"errors":0}, for breaking down workloads
+// that span buckets. Failed reads count as reads and errors but add no bytes.
+// A bucket never read from has all zeros. Returns the number of bytes written,
+// excluding the terminator, or a negative error code on error, including if
+// buf is too small.
+//
+//export GoStorageGetBucketStats
+func GoStorageGetBucketStats(td uintptr, bucketCstr, buf *C.char, bufLen C.int) int {
+	bucket := C.GoString(bucketCstr)
+	slog.Debug("go storage get bucket stats",
+		"td", td,
+		"bucket", bucket,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("get bucket stats: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	var stats bucketStatsJSON
+	t.bucketStatsMu.RLock()
+	if e := t.bucketStats[bucket]; e != nil {
+		stats = bucketStatsJSON{
+			BytesRead: e.totalBytesRead.Load(),
+			Reads:     e.totalReads.Load(),
+			Errors:    e.totalErrors.Load(),
+		}
+	}
+	t.bucketStatsMu.RUnlock()
+	b, err := json.Marshal(stats)
+	if err != nil {
+		slog.Error("get bucket stats: marshal failed", "err", err)
+		return int(codeUnknown)
+	}
+	n := writeCString(buf, bufLen, string(b))
+	if n < 0 {
+		slog.Error("get bucket stats: buffer too small", "buf_len", bufLen)
+	}
+	return n
+}
@@ -0,0 +1,85 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"encoding/json"
+	"log/slog"
+	"runtime"
+	"runtime/debug"
+)
+
+// Populated at link time, e.g. with
+//
+//	go build -ldflags "-X main.version=v1.2.3 -X main.buildTime=$(date -u +%FT%TZ) -X main.gitCommit=$(git rev-parse HEAD)"
+var (
+	version   = "devel"
+	buildTime = ""
+	gitCommit = ""
+)
+
+const storageModulePath = "cloud.google.com/go/storage"
+
+type buildInfo struct {
+	Version              string `json:"version"`
+	BuildTime            string `json:"buildTime"`
+	GoVersion            string `json:"goVersion"`
+	StorageClientVersion string `json:"storageClientVersion"`
+	GitCommit            string `json:"gitCommit"`
+}
+
+func readBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:   version,
+		BuildTime: buildTime,
+		GoVersion: runtime.Version(),
+		GitCommit: gitCommit,
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, dep := range bi.Deps {
+		if dep.Path == storageModulePath {
+			info.StorageClientVersion = dep.Version
+		}
+	}
+	if info.GitCommit == "" {
+		for _, setting := range bi.Settings {
+			if setting.Key == "vcs.revision" {
+				info.GitCommit = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+// GoStorageGetBuildInfo writes a NUL-terminated JSON description of this
+// library's build into buf, to help diagnose version skew between the engine
+// and fio. Returns the number of bytes written, excluding the terminator, or a
+// negative error code if buf is too small.
+//
+//export GoStorageGetBuildInfo
+func GoStorageGetBuildInfo(buf *C.char, bufLen C.int) int {
+	b, err := json.Marshal(readBuildInfo())
+	if err != nil {
+		slog.Error("get build info: marshal failed", "err", err)
+		return int(codeUnknown)
+	}
+	return writeCString(buf, bufLen, string(b))
+}
+
+// GoStorageGetGoVersion writes the NUL-terminated Go runtime version into buf.
+// Returns the number of bytes written, excluding the terminator, or a negative
+// error code if buf is too small.
+//
+//export GoStorageGetGoVersion
+func GoStorageGetGoVersion(buf *C.char, bufLen C.int) int {
+	return writeCString(buf, bufLen, runtime.Version())
+}
@@ -0,0 +1,633 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+
+	// Registers the gzip compressor for GoStorageSetGRPCCompression and
+	// GoStorageSetTransportCompression.
+	_ "google.golang.org/grpc/encoding/gzip"
+)
+
+// Load balancing policies accepted by GoStorageInitWithLBPolicy.
+var lbPolicies = map[string]bool{
+	"round_robin": true,
+	"pick_first":  true,
+	"grpclb":      true,
+}
+
+// GoStorageInitWithLBPolicy is like GoStorageInit, but configures the gRPC
+// load balancing policy. An empty policy selects round_robin, which is what
+// the GCS gRPC best practices recommend for multi-IP endpoints.
+//
+//export GoStorageInitWithLBPolicy
+func GoStorageInitWithLBPolicy(iodepth uint, lbPolicyCstr *C.char) uintptr {
+	lbPolicy := C.GoString(lbPolicyCstr)
+	if lbPolicy == "" {
+		lbPolicy = "round_robin"
+	}
+	slog.Info("go storage init with lb policy",
+		"iodepth", iodepth,
+		"lb_policy", lbPolicy,
+	)
+	if !lbPolicies[lbPolicy] {
+		slog.Error("unsupported load balancing policy", "lb_policy", lbPolicy)
+		return 0
+	}
+	return initThreadData(iodepth, clientConfig{lbPolicy: lbPolicy}, false)
+}
+
+// Smallest transport buffer accepted by GoStorageInitWithSocketBuffers.
+const minSocketBufferSize = 4096
+
+// GoStorageInitWithSocketBuffers is like GoStorageInit, but sizes the gRPC
+// transport read and write buffers. These are gRPC-Go's buffers rather than
+// the OS socket buffers, but are what bounds single-stream throughput on
+// high-bandwidth VMs.
+//
+//export GoStorageInitWithSocketBuffers
+func GoStorageInitWithSocketBuffers(iodepth uint, recvBufBytes, sendBufBytes C.int) uintptr {
+	slog.Info("go storage init with socket buffers",
+		"iodepth", iodepth,
+		"recv_buf_bytes", recvBufBytes,
+		"send_buf_bytes", sendBufBytes,
+	)
+	if recvBufBytes < minSocketBufferSize || sendBufBytes < minSocketBufferSize {
+		slog.Error("socket buffers too small",
+			"recv_buf_bytes", recvBufBytes,
+			"send_buf_bytes", sendBufBytes,
+			"min", minSocketBufferSize,
+		)
+		return 0
+	}
+	cfg := clientConfig{
+		readBufferSize:  int(recvBufBytes),
+		writeBufferSize: int(sendBufBytes),
+	}
+	return initThreadData(iodepth, cfg, false)
+}
+
+// Transport buffer sizes accepted by GoStorageInitWithBuffers, in KiB.
+const (
+	minBufferKB = 4
+	maxBufferKB = 65536
+)
+
+// GoStorageInitWithBuffers is like GoStorageInitWithSocketBuffers, but takes
+// the transport read and write buffer sizes in KiB, each between 4 and 65536.
+// gRPC-Go's 32 KiB defaults hold back high-throughput VMs; 4096 KiB for reads
+// and 1024 KiB for writes are good starting points. Returns 0 on error.
+//
+//export GoStorageInitWithBuffers
+func GoStorageInitWithBuffers(iodepth uint, recvBufKB, sendBufKB C.int) uintptr {
+	slog.Info("go storage init with buffers",
+		"iodepth", iodepth,
+		"recv_buf_kb", recvBufKB,
+		"send_buf_kb", sendBufKB,
+	)
+	if recvBufKB < minBufferKB || recvBufKB > maxBufferKB || sendBufKB < minBufferKB || sendBufKB > maxBufferKB {
+		slog.Error("buffer sizes out of range",
+			"recv_buf_kb", recvBufKB,
+			"send_buf_kb", sendBufKB,
+			"min_kb", minBufferKB,
+			"max_kb", maxBufferKB,
+		)
+		return 0
+	}
+	cfg := clientConfig{
+		readBufferSize:  int(recvBufKB) << 10,
+		writeBufferSize: int(sendBufKB) << 10,
+	}
+	return initThreadData(iodepth, cfg, false)
+}
+
+// GoStorageInitWithHeaderListSize is like GoStorageInit, but accepts response
+// header lists from GCS of up to maxHeaderListSizeBytes, which must be
+// positive, for objects whose custom metadata exceeds gRPC-Go's default limit.
+// This bounds only what the client accepts; GCS's own limits on request
+// headers still apply. Returns 0 on error.
+//
+//export GoStorageInitWithHeaderListSize
+func GoStorageInitWithHeaderListSize(iodepth uint, maxHeaderListSizeBytes int32) uintptr {
+	slog.Info("go storage init with header list size",
+		"iodepth", iodepth,
+		"max_header_list_size_bytes", maxHeaderListSizeBytes,
+	)
+	if maxHeaderListSizeBytes <= 0 {
+		slog.Error("init with header list size: invalid size", "max_header_list_size_bytes", maxHeaderListSizeBytes)
+		return 0
+	}
+	return initThreadData(iodepth, clientConfig{maxHeaderListSize: uint32(maxHeaderListSizeBytes)}, false)
+}
+
+// GoStorageInitWithScopes is like GoStorageInit, but requests only the
+// scopeCount OAuth2 scopes in scopes for the client's credentials, e.g. just
+// "https://www.googleapis.com/auth/devstorage.read_only" for read-only
+// benchmarks, to limit what leaked credentials could do. A scopeCount of 0
+// requests the default scopes, with a warning. Returns 0 on error.
+//
+//export GoStorageInitWithScopes
+func GoStorageInitWithScopes(iodepth uint, scopes **C.char, scopeCount C.int) uintptr {
+	if scopeCount < 0 || (scopes == nil && scopeCount > 0) {
+		slog.Error("init with scopes: invalid scopes", "scope_count", scopeCount)
+		return 0
+	}
+	var scopeList []string
+	for _, s := range unsafe.Slice(scopes, int(scopeCount)) {
+		scope := C.GoString(s)
+		if scope == "" || strings.ContainsAny(scope, " \t\n") {
+			slog.Error("init with scopes: invalid scope", "scope", scope)
+			return 0
+		}
+		scopeList = append(scopeList, scope)
+	}
+	slog.Info("go storage init with scopes",
+		"iodepth", iodepth,
+		"scopes", scopeList,
+	)
+	if len(scopeList) == 0 {
+		slog.Warn("init with scopes: no scopes given, using default scopes")
+	}
+	return initThreadData(iodepth, clientConfig{scopes: strings.Join(scopeList, " ")}, false)
+}
+
+// GoStorageInitWithJSONReadFallback is like GoStorageInit, but also passes
+// storage.WithJSONReads, for environments without DirectPath where the gRPC
+// client is still preferred for metadata operations.
+//
+// The option only affects reads made with storage.Reader. Reads through a
+// MultiRangeDownloader always use the gRPC bidi read API, so they may behave
+// differently from plain reads under this mode.
+//
+//export GoStorageInitWithJSONReadFallback
+func GoStorageInitWithJSONReadFallback(iodepth uint) uintptr {
+	slog.Info("go storage init with json read fallback", "iodepth", iodepth)
+	return initThreadData(iodepth, clientConfig{jsonReads: true}, false)
+}
+
+// GoStorageSetGRPCCompression sets the compressor used for gRPC messages:
+// "gzip", or "identity" or "" for none. Compression helps compressible data at
+// the cost of CPU, and only costs CPU for random data. The client is rebuilt if
+// the setting changes, which fails with GO_STORAGE_ERR_INVALID_ARGUMENT while
+// files are open or operations are in flight on td. Returns 0 on success and
+// a negative error code on error.
+//
+//export GoStorageSetGRPCCompression
+func GoStorageSetGRPCCompression(td uintptr, algorithmCstr *C.char) int {
+	algorithm := C.GoString(algorithmCstr)
+	slog.Info("go storage set grpc compression",
+		"td", td,
+		"algorithm", algorithm,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set grpc compression: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	switch algorithm {
+	case "identity":
+		algorithm = ""
+	case "", "gzip":
+	default:
+		slog.Error("unsupported grpc compression", "algorithm", algorithm)
+		return int(codeInvalidArgument)
+	}
+	if err := t.setCompressor(algorithm); err != nil {
+		slog.Error("set grpc compression: failed to rebuild client", "err", err)
+		return int(errorCodeOf(err))
+	}
+	return 0
+}
+
+// setCompressor rebuilds t's client to compress gRPC messages with the named
+// registered compressor, or none if algorithm is empty.
+func (t *threadData) setCompressor(algorithm string) error {
+	cfg := t.config()
+	if algorithm == cfg.compressor {
+		return nil
+	}
+	cfg.compressor = algorithm
+	return t.reconfigure(cfg)
+}
+
+// GoStorageSetTransportCompression sets the compressor used for gRPC messages
+// by name: "gzip", "snappy", or "none", the default. Any compressor registered
+// with gRPC's encoding package is accepted, so "snappy", which costs less CPU
+// than gzip for a smaller reduction in bandwidth, is only available in builds
+// that link in a Snappy compressor; none is linked in by default. As with
+// GoStorageSetGRPCCompression, the client is rebuilt if the setting changes.
+// Returns 0 on success and a negative error code on error.
+//
+//export GoStorageSetTransportCompression
+func GoStorageSetTransportCompression(td uintptr, algorithmCstr *C.char) int {
+	algorithm := C.GoString(algorithmCstr)
+	slog.Info("go storage set transport compression",
+		"td", td,
+		"algorithm", algorithm,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set transport compression: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	switch algorithm {
+	case "none":
+		algorithm = ""
+	case "gzip", "snappy":
+		if encoding.GetCompressor(algorithm) == nil {
+			slog.Error("set transport compression: compressor not compiled in", "algorithm", algorithm)
+			return int(codeInvalidArgument)
+		}
+	default:
+		slog.Error("unsupported transport compression", "algorithm", algorithm)
+		return int(codeInvalidArgument)
+	}
+	if err := t.setCompressor(algorithm); err != nil {
+		slog.Error("set transport compression: failed to rebuild client", "err", err)
+		return int(errorCodeOf(err))
+	}
+	return 0
+}
+
+// Object looked up by GoStoragePrewarm. It is not expected to exist.
+const prewarmObject = "__prewarm__"
+
+// GoStoragePrewarm starts establishing the client's gRPC connection in the
+// background, so that the first open doesn't pay for connection setup on top
+// of its own metadata round trip. It does so with a cheap metadata lookup in
+// bucket, which is expected to fail with NotFound. Returns 0 once the lookup
+// has been started, or a negative error code on error.
+//
+//export GoStoragePrewarm
+func GoStoragePrewarm(td uintptr, bucketCstr *C.char) int {
+	bucket := C.GoString(bucketCstr)
+	slog.Debug("go storage prewarm",
+		"td", td,
+		"bucket", bucket,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("prewarm: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+
+	oh := t.bucket(bucket).Object(prewarmObject)
+	done := t.beginClientOp()
+	go func() {
+		defer done()
+		_, err := oh.Attrs(context.Background())
+		if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+			slog.Debug("prewarm lookup failed",
+				"bucket", bucket,
+				"err", err,
+			)
+		}
+	}()
+	return 0
+}
+
+// GoStorageInitWithDialTimeout is like GoStorageInit, but fails if creating
+// the client takes longer than dialTimeoutMs milliseconds. A dialTimeoutMs of 0
+// applies no deadline, leaving gRPC to connect lazily as usual.
+//
+//export GoStorageInitWithDialTimeout
+func GoStorageInitWithDialTimeout(iodepth uint, dialTimeoutMs int64) uintptr {
+	dialTimeout := time.Duration(dialTimeoutMs) * time.Millisecond
+	slog.Info("go storage init with dial timeout",
+		"iodepth", iodepth,
+		"dial_timeout", dialTimeout,
+	)
+	if dialTimeout < 0 {
+		slog.Error("negative dial timeout", "dial_timeout", dialTimeout)
+		return 0
+	}
+	return initThreadData(iodepth, clientConfig{dialTimeout: dialTimeout}, false)
+}
+
+// GoStorageInitShared is like GoStorageInit with share_client set: every
+// thread initialized this way uses the same client, and so the same gRPC
+// connections. The client is closed when the last of those threads is cleaned
+// up.
+//
+//export GoStorageInitShared
+func GoStorageInitShared(iodepth uint) uintptr {
+	slog.Info("go storage init shared", "iodepth", iodepth)
+	return initThreadData(iodepth, clientConfig{}, true)
+}
+
+// GoStorageSetGRPCConnectBackoff configures the backoff gRPC uses between
+// connection attempts. This is separate from, and happens below, the retries
+// of individual storage operations. The client is rebuilt, which fails with
+// GO_STORAGE_ERR_INVALID_ARGUMENT while files are open or operations are in
+// flight on td. Returns 0 on success and a negative error code on error.
+//
+//export GoStorageSetGRPCConnectBackoff
+func GoStorageSetGRPCConnectBackoff(td uintptr, baseDelayMs int64, multiplier, jitterFraction float64, maxDelayMs int64) int {
+	cfg := backoff.Config{
+		BaseDelay:  time.Duration(baseDelayMs) * time.Millisecond,
+		Multiplier: multiplier,
+		Jitter:     jitterFraction,
+		MaxDelay:   time.Duration(maxDelayMs) * time.Millisecond,
+	}
+	slog.Info("go storage set grpc connect backoff",
+		"td", td,
+		"base_delay", cfg.BaseDelay,
+		"multiplier", cfg.Multiplier,
+		"jitter", cfg.Jitter,
+		"max_delay", cfg.MaxDelay,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set grpc connect backoff: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if baseDelayMs < 1 || multiplier < 1 || jitterFraction < 0 || jitterFraction > 1 || cfg.MaxDelay < cfg.BaseDelay {
+		slog.Error("invalid grpc connect backoff")
+		return int(codeInvalidArgument)
+	}
+
+	clientCfg := t.config()
+	clientCfg.connectBackoff = cfg
+	if err := t.reconfigure(clientCfg); err != nil {
+		slog.Error("set grpc connect backoff: failed to rebuild client", "err", err)
+		return int(errorCodeOf(err))
+	}
+	return 0
+}
+
+type connectBackoffJSON struct {
+	BaseDelayMs int64   `json:"baseDelayMs"`
+	Multiplier  float64 `json:"multiplier"`
+	Jitter      float64 `json:"jitter"`
+	MaxDelayMs  int64   `json:"maxDelayMs"`
+}
+
+// GoStorageGetGRPCConnectParams writes td's gRPC connection backoff, as
+// NUL-terminated JSON, into buf. Returns the number of bytes written,
+// excluding the terminator, or a negative error code on error or if buf is too
+// small.
+//
+//export GoStorageGetGRPCConnectParams
+func GoStorageGetGRPCConnectParams(td uintptr, buf *C.char, bufLen C.int) int {
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("get grpc connect params: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	cfg := t.config().connectBackoff
+	if cfg == (backoff.Config{}) {
+		cfg = backoff.DefaultConfig
+	}
+	b, err := json.Marshal(connectBackoffJSON{
+		BaseDelayMs: cfg.BaseDelay.Milliseconds(),
+		Multiplier:  cfg.Multiplier,
+		Jitter:      cfg.Jitter,
+		MaxDelayMs:  cfg.MaxDelay.Milliseconds(),
+	})
+	if err != nil {
+		slog.Error("get grpc connect params: marshal failed", "err", err)
+		return int(codeUnknown)
+	}
+	return writeCString(buf, bufLen, string(b))
+}
+
+// How often client metrics are exported; the Cloud Monitoring default.
+const metricInterval = 60 * time.Second
+
+// GoStorageInitWithMetrics is like GoStorageInit, but exports client metrics
+// to Cloud Monitoring, billed to projectID. Metrics startup adds a few seconds
+// to client creation.
+//
+//export GoStorageInitWithMetrics
+func GoStorageInitWithMetrics(iodepth uint, projectIDCstr *C.char) uintptr {
+	projectID := C.GoString(projectIDCstr)
+	slog.Info("go storage init with metrics",
+		"iodepth", iodepth,
+		"project_id", projectID,
+	)
+	slog.Warn("client metrics enabled; client startup may take a few seconds")
+	cfg := clientConfig{
+		clientMetrics: true,
+		quotaProject:  projectID,
+	}
+	return initThreadData(iodepth, cfg, false)
+}
+
+// GoStorageInitNoMetrics is GoStorageInit, for callers that want to make the
+// choice to disable client metrics explicit.
+//
+//export GoStorageInitNoMetrics
+func GoStorageInitNoMetrics(iodepth uint, endpoint_override *C.char, connection_pool_size int, share_client bool) uintptr {
+	return GoStorageInit(iodepth, endpoint_override, connection_pool_size, share_client)
+}
+
+// probeConnection makes a single metadata request on c, for bucket, returning
+// an error only if it couldn't reach GCS. Any response from GCS, including
+// NotFound or PermissionDenied, shows the connection works. The bucket should
+// be one the caller owns, since its owner can see the request in their audit
+// logs.
+func probeConnection(ctx context.Context, c *storage.Client, bucket string) error {
+	_, err := c.Bucket(bucket).
+		Retryer(storage.WithPolicy(storage.RetryNever)).
+		Attrs(ctx)
+	if code := status.Code(err); code == codes.Unavailable || code == codes.DeadlineExceeded || code == codes.Unknown {
+		return fmt.Errorf("probing connection: %w", err)
+	}
+	return nil
+}
+
+// GoStorageInitWithConnectRetry is like GoStorageInit, but makes sure the
+// client can reach GCS before returning, by looking up probeBucket, which
+// should be a bucket the benchmark uses, retrying client creation up to
+// maxAttempts times with retryDelayMs milliseconds between attempts. This
+// covers the initial connection only; operations have their own retries.
+//
+//export GoStorageInitWithConnectRetry
+func GoStorageInitWithConnectRetry(iodepth uint, probeBucketCstr *C.char, maxAttempts C.int, retryDelayMs int64) uintptr {
+	probeBucket := C.GoString(probeBucketCstr)
+	retryDelay := time.Duration(retryDelayMs) * time.Millisecond
+	slog.Info("go storage init with connect retry",
+		"iodepth", iodepth,
+		"probe_bucket", probeBucket,
+		"max_attempts", maxAttempts,
+		"retry_delay", retryDelay,
+	)
+	if probeBucket == "" || maxAttempts < 1 || retryDelay < 0 {
+		slog.Error("invalid connect retry")
+		return 0
+	}
+
+	var cfg clientConfig
+	for attempt := 1; ; attempt++ {
+		c, err := makeClient(cfg)
+		if err == nil {
+			if err = probeConnection(context.Background(), c, probeBucket); err == nil {
+				return newThreadData(iodepth, cfg, c, false)
+			}
+			if err := c.Close(); err != nil {
+				slog.Error("go storage close error (swallowing)", "err", err)
+			}
+		}
+		slog.Warn("connect attempt failed",
+			"attempt", attempt,
+			"max_attempts", maxAttempts,
+			"err", err,
+		)
+		if attempt >= int(maxAttempts) {
+			slog.Error("all connect attempts failed", "max_attempts", maxAttempts)
+			return 0
+		}
+		time.Sleep(retryDelay)
+	}
+}
+
+// Environment variable that, set to "true", stops clients from using
+// DirectPath. The storage client always asks for DirectPath, with xDS, so
+// this is the only way to turn it off; there's no variable that enables it.
+const disableDirectPathEnvVar = "GOOGLE_CLOUD_DISABLE_DIRECT_PATH"
+
+var (
+	directPathMu sync.Mutex
+	// The last setting made by setDirectPath, if any.
+	directPathSetting *bool
+)
+
+// setDirectPath allows or disables DirectPath for clients created afterwards,
+// warning if that changes an earlier setting.
+func setDirectPath(enable bool) error {
+	directPathMu.Lock()
+	defer directPathMu.Unlock()
+	if directPathSetting != nil && *directPathSetting != enable {
+		slog.Warn("changing direct path setting for the whole process",
+			"was", *directPathSetting,
+			"now", enable,
+		)
+	}
+	directPathSetting = &enable
+	if enable {
+		if err := os.Unsetenv(disableDirectPathEnvVar); err != nil {
+			return fmt.Errorf("unsetting %s: %w", disableDirectPathEnvVar, err)
+		}
+		return nil
+	}
+	if err := os.Setenv(disableDirectPathEnvVar, "true"); err != nil {
+		return fmt.Errorf("setting %s: %w", disableDirectPathEnvVar, err)
+	}
+	return nil
+}
+
+// GoStorageInitWithDirectPath is like GoStorageInit, but explicitly allows or
+// disables DirectPath, which routes traffic straight to GCS backends on GCE
+// but fails on VMs without the required network setup. Allowing it only
+// undoes disabling it: the client still uses DirectPath only on GCE with
+// compatible credentials. The setting is made through
+// GOOGLE_CLOUD_DISABLE_DIRECT_PATH, so it applies to every client created
+// afterwards, not just this thread's.
+//
+//export GoStorageInitWithDirectPath
+func GoStorageInitWithDirectPath(iodepth uint, enableDirectPath bool) uintptr {
+	slog.Info("go storage init with direct path",
+		"iodepth", iodepth,
+		"enable_direct_path", enableDirectPath,
+	)
+	if err := setDirectPath(enableDirectPath); err != nil {
+		slog.Error("failed to set direct path environment", "err", err)
+		return 0
+	}
+	return initThreadData(iodepth, clientConfig{}, false)
+}
+
+// User agent sent by clients created by GoStorageInitGCSFuseMode.
+const gcsfuseCompatUserAgent = "go-storage-fio-engine (gcsfuse-compat)"
+
+// GoStorageInitGCSFuseMode is like GoStorageInit, but avoids conflicting with
+// Cloud Storage FUSE running on the same VM: DirectPath, and with it the
+// client's xDS-based traffic management, is disabled, as with
+// GoStorageInitWithDirectPath, leaving xDS to gcsfuse; gRPC uses the native
+// DNS resolver; and requests are marked with a "(gcsfuse-compat)" user agent.
+// The DirectPath and resolver settings apply to the whole process.
+//
+//export GoStorageInitGCSFuseMode
+func GoStorageInitGCSFuseMode(iodepth uint) uintptr {
+	slog.Info("go storage init gcsfuse mode", "iodepth", iodepth)
+	if err := setDirectPath(false); err != nil {
+		slog.Error("failed to set direct path environment", "err", err)
+		return 0
+	}
+	slog.Warn("gcsfuse compatibility mode disables direct path for the whole process")
+	// gRPC-Go always resolves with Go's resolver; this is for any gRPC C-core
+	// code loaded into the process, such as other fio engines.
+	if err := os.Setenv("GRPC_DNS_RESOLVER", "native"); err != nil {
+		slog.Error("failed to set grpc dns resolver", "err", err)
+		return 0
+	}
+	return initThreadData(iodepth, clientConfig{userAgent: gcsfuseCompatUserAgent}, false)
+}
+
+// GoStorageInitWithGRPCMessageLimits is like GoStorageInit, but sets the
+// largest gRPC messages the client sends and receives, to match a server or
+// proxy with different limits. A non-positive limit keeps gRPC's default.
+//
+//export GoStorageInitWithGRPCMessageLimits
+func GoStorageInitWithGRPCMessageLimits(iodepth uint, maxSendBytes, maxRecvBytes int32) uintptr {
+	slog.Info("go storage init with grpc message limits",
+		"iodepth", iodepth,
+		"max_send_bytes", maxSendBytes,
+		"max_recv_bytes", maxRecvBytes,
+	)
+	cfg := clientConfig{
+		maxSendMsgSize: int(max(maxSendBytes, 0)),
+		maxRecvMsgSize: int(max(maxRecvBytes, 0)),
+	}
+	return initThreadData(iodepth, cfg, false)
+}
+
+// GoStorageGetGRPCMessageLimits writes td's gRPC message size limits to *send
+// and *recv, or -1 for limits left at gRPC's default. Either may be NULL.
+// Returns 0 on success and a negative error code on error.
+//
+//export GoStorageGetGRPCMessageLimits
+func GoStorageGetGRPCMessageLimits(td uintptr, send, recv *int32) int {
+	slog.Debug("go storage get grpc message limits", "td", td)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("get grpc message limits: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	cfg := t.config()
+	limit := func(size int) int32 {
+		if size == 0 {
+			return -1
+		}
+		return int32(size)
+	}
+	if send != nil {
+		*send = limit(cfg.maxSendMsgSize)
+	}
+	if recv != nil {
+		*recv = limit(cfg.maxRecvMsgSize)
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"cloud.google.com/go/storage"
+)
+
+// copyRange writes the length bytes at offset in src to a new dst object.
+func copyRange(ctx context.Context, src, dst *storage.ObjectHandle, offset, length int64) error {
+	rr, err := src.NewRangeReader(ctx, offset, length)
+	if err != nil {
+		return fmt.Errorf("opening range reader: %w", err)
+	}
+	data, err := io.ReadAll(rr)
+	if closeErr := rr.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("reading source range: %w", err)
+	}
+
+	w := dst.NewWriter(ctx)
+	if _, err := w.Write(data); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("writing destination: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("closing destination writer: %w", err)
+	}
+	return nil
+}
+
+// GoStorageCopyRange copies the length bytes at offset in srcFile to a new
+// object dstFile, replacing any existing object, by reading the range and
+// uploading it. It completes asynchronously on td with a NULL iou, so callers
+// reaping completions must expect one. Returns 1 if queued, and a negative
+// error code on error.
+//
+//export GoStorageCopyRange
+func GoStorageCopyRange(td uintptr, srcFileCstr, dstFileCstr *C.char, offset, length int64) int {
+	srcFile := C.GoString(srcFileCstr)
+	dstFile := C.GoString(dstFileCstr)
+	slog.Debug("go storage copy range",
+		"td", td,
+		"src_file", srcFile,
+		"dst_file", dstFile,
+		"offset", offset,
+		"length", length,
+	)
+	if offset < 0 || length <= 0 {
+		slog.Error("copy range: invalid range",
+			"offset", offset,
+			"length", length,
+		)
+		return int(codeInvalidArgument)
+	}
+	t, src, err := filenameObjectHandle(td, srcFile)
+	if err != nil {
+		slog.Error("copy range: error getting source *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+	_, dst, err := filenameObjectHandle(td, dstFile)
+	if err != nil {
+		slog.Error("copy range: error getting destination *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	done := t.beginClientOp()
+	t.spawn(func() {
+		defer done()
+		err := copyRange(context.Background(), src, dst, offset, length)
+		if err != nil {
+			slog.Error("copy range failed",
+				"src_file", srcFile,
+				"dst_file", dstFile,
+				"err", err,
+			)
+		}
+		t.complete(nil, err)
+	})
+	return fioQQueued
+}
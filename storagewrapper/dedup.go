@@ -0,0 +1,151 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Whether identical reads in flight at once are deduplicated; see
+// GoStorageEnableRequestDedup.
+var dedupEnabled atomic.Bool
+
+var (
+	// dedupReads maps the key of each deduplicated read in flight to its
+	// dedupRead.
+	dedupReads sync.Map
+	// dedupLeaders maps the iou of each deduplicated read in flight to its
+	// dedupRead.
+	dedupLeaders sync.Map
+)
+
+// threadFile is implemented by read files, returning the thread they were
+// opened on.
+type threadFile interface {
+	thread() *threadData
+}
+
+// dedupFile is implemented by read files whose reads can be deduplicated.
+type dedupFile interface {
+	infoFile
+	threadFile
+}
+
+func (m *mrdFile) thread() *threadData {
+	return m.t
+}
+
+func (o *oDirectMrdFile) thread() *threadData {
+	return o.t
+}
+
+func (r *rangeReaderFile) thread() *threadData {
+	return r.t
+}
+
+func (m *multiStreamMrdFile) thread() *threadData {
+	return m.t
+}
+
+// dedupWaiter is a read waiting on an identical one already in flight.
+type dedupWaiter struct {
+	t   *threadData
+	iou unsafe.Pointer
+}
+
+// dedupRead is a read in flight that identical reads wait on rather than
+// requesting the same range again.
+type dedupRead struct {
+	key string
+
+	mu      sync.Mutex
+	done    bool
+	waiters []dedupWaiter
+}
+
+// wait registers iou to complete on t along with d, returning false if d has
+// already completed.
+func (d *dedupRead) wait(t *threadData, iou unsafe.Pointer) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.done {
+		return false
+	}
+	d.waiters = append(d.waiters, dedupWaiter{t, iou})
+	return true
+}
+
+// finish completes every read waiting on d with err.
+func (d *dedupRead) finish(err error) {
+	dedupReads.CompareAndDelete(d.key, d)
+	d.mu.Lock()
+	d.done = true
+	waiters := d.waiters
+	d.waiters = nil
+	d.mu.Unlock()
+	for _, w := range waiters {
+		w.t.complete(w.iou, err)
+	}
+}
+
+// completeDedup completes the reads waiting on iou, if it is a deduplicated
+// read, with err.
+func completeDedup(iou unsafe.Pointer, err error) {
+	if d, ok := dedupLeaders.LoadAndDelete(iou); ok {
+		d.(*dedupRead).finish(err)
+	}
+}
+
+// queueDeduplicated queues iou to read n bytes at offset on file v, like
+// trackInflight, unless an identical read of the same object is already in
+// flight, in which case iou completes along with that read instead.
+func queueDeduplicated(f dedupFile, v uintptr, iou unsafe.Pointer, offset, n int64, enqueue func() int) int {
+	t := f.thread()
+	oh := f.info().oh
+	d := &dedupRead{key: fmt.Sprintf("%s/%s@%d:%d", oh.BucketName(), oh.ObjectName(), offset, n)}
+	if prev, loaded := dedupReads.LoadOrStore(d.key, d); loaded {
+		return trackInflight(v, iou, offset, n, func() int {
+			if prev.(*dedupRead).wait(t, iou) {
+				return fioQQueued
+			}
+			// The read finished while being looked up, so read again.
+			return enqueue()
+		})
+	}
+
+	dedupLeaders.Store(iou, d)
+	res := trackInflight(v, iou, offset, n, enqueue)
+	if res != fioQQueued {
+		dedupLeaders.Delete(iou)
+		var err error
+		if res < 0 {
+			err = fmt.Errorf("deduplicated read failed to queue: %v", errorCode(res))
+		}
+		d.finish(err)
+	}
+	return res
+}
+
+// GoStorageEnableRequestDedup deduplicates identical reads, of the same range
+// of the same object, that are in flight at once on any thread, as happens when
+// several fio jobs read the same file in step. Only the first is requested from
+// GCS, and the rest complete along with it, with its result. The reads are
+// queued as usual either way. Applies to reads queued with GoStorageQueue from
+// now on. Returns 0.
+//
+//export GoStorageEnableRequestDedup
+func GoStorageEnableRequestDedup(enabled bool) int {
+	slog.Info("go storage enable request dedup", "enabled", enabled)
+	dedupEnabled.Store(enabled)
+	return 0
+}
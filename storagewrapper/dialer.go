@@ -0,0 +1,92 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+/*
+#include <stdlib.h>
+
+// Connects to address, a "host:port" string, by any means, storing a connected
+// stream socket in *fd, which GoStorage takes ownership of. Returns 0 on
+// success and non-zero on failure. May be called from any thread, possibly
+// concurrently with itself.
+typedef int (*GoStorageDialFunc)(const char* address, int* fd);
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"unsafe"
+
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+// cDialerOption returns an option dialing gRPC connections with C dialer fn,
+// a GoStorageDialFunc.
+func cDialerOption(fn unsafe.Pointer) option.ClientOption {
+	return option.WithGRPCDialOption(grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("dialing %v: %w", addr, err)
+		}
+		caddr := C.CString(addr)
+		defer C.free(unsafe.Pointer(caddr))
+		res, fd := callDialFunc(fn, caddr)
+		if res != 0 {
+			return nil, fmt.Errorf("dialing %v: custom dialer failed with %d", addr, res)
+		}
+		f := os.NewFile(uintptr(fd), addr)
+		// FileConn duplicates the descriptor, so f is closed either way.
+		defer f.Close()
+		conn, err := net.FileConn(f)
+		if err != nil {
+			return nil, fmt.Errorf("wrapping connection to %v: %w", addr, err)
+		}
+		return conn, nil
+	}))
+}
+
+// GoStorageInitWithDialer is like GoStorageInit, but dials every gRPC
+// connection with dialFn, a GoStorageDialFunc, so that benchmarks can use
+// transports Go doesn't support natively. The client must reach GCS through
+// dialFn, checked by looking up probeBucket, which should be a bucket the
+// benchmark uses, before this returns. Returns 0 on error.
+//
+//export GoStorageInitWithDialer
+func GoStorageInitWithDialer(iodepth uint, dialFn unsafe.Pointer, probeBucketCstr *C.char) uintptr {
+	probeBucket := C.GoString(probeBucketCstr)
+	slog.Info("go storage init with dialer",
+		"iodepth", iodepth,
+		"probe_bucket", probeBucket,
+	)
+	if dialFn == nil {
+		slog.Error("init with dialer: NULL dialer")
+		return 0
+	}
+	if probeBucket == "" {
+		slog.Error("init with dialer: empty probe bucket")
+		return 0
+	}
+
+	cfg := clientConfig{dialFn: dialFn}
+	c, err := makeClient(cfg)
+	if err != nil {
+		slog.Error("failed client creation", "err", err)
+		return 0
+	}
+	if err := probeConnection(context.Background(), c, probeBucket); err != nil {
+		slog.Error("init with dialer: could not reach GCS", "err", err)
+		if err := c.Close(); err != nil {
+			slog.Error("go storage close error (swallowing)", "err", err)
+		}
+		return 0
+	}
+	return newThreadData(iodepth, cfg, c, false)
+}
@@ -0,0 +1,29 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+// Go can't call C function pointers directly, so this file holds the C side of
+// dialer.go. Files with exports may only declare C functions, so it has none.
+
+/*
+typedef int (*GoStorageDialFunc)(const char* address, int* fd);
+
+static int call_dial_func(void* fn, const char* address, int* fd) {
+	return ((GoStorageDialFunc)fn)(address, fd);
+}
+*/
+import "C"
+
+import "unsafe"
+
+// callDialFunc calls C dialer fn for address, returning its result and the
+// file descriptor it connected.
+func callDialFunc(fn unsafe.Pointer, address *C.char) (res, fd int) {
+	var cfd C.int
+	res = int(C.call_dial_func(fn, address, &cfd))
+	return res, int(cfd)
+}
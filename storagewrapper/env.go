@@ -0,0 +1,147 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Environment variables read by GoStorageInitFromEnvironment.
+const (
+	envIODepth         = "STORAGEWRAPPER_IODEPTH"
+	envTransport       = "STORAGEWRAPPER_TRANSPORT"
+	envMaxRetries      = "STORAGEWRAPPER_MAX_RETRIES"
+	envTimeoutMs       = "STORAGEWRAPPER_TIMEOUT_MS"
+	envLogLevel        = "STORAGEWRAPPER_LOG_LEVEL"
+	envServiceEndpoint = "STORAGEWRAPPER_SERVICE_ENDPOINT"
+	envProjectID       = "STORAGEWRAPPER_PROJECT_ID"
+	envCredentialsFile = "STORAGEWRAPPER_CREDENTIALS_FILE"
+)
+
+// envInt returns the integer in environment variable name, or def if it is
+// unset or empty.
+func envInt(name string, def int64) (int64, error) {
+	s := os.Getenv(name)
+	if s == "" {
+		return def, nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	return v, nil
+}
+
+// envConfig is the configuration read by GoStorageInitFromEnvironment.
+type envConfig struct {
+	iodepth uint
+	cfg     clientConfig
+	timeout time.Duration
+}
+
+func readEnvConfig() (envConfig, error) {
+	var ec envConfig
+	iodepth, err := envInt(envIODepth, 1)
+	if err != nil {
+		return ec, err
+	}
+	if iodepth < 1 {
+		return ec, fmt.Errorf("%s must be positive: %w", envIODepth, errInvalidArgument)
+	}
+	ec.iodepth = uint(iodepth)
+
+	switch transport := os.Getenv(envTransport); transport {
+	case "", "grpc":
+	case "json":
+		ec.cfg.jsonReads = true
+	default:
+		return ec, fmt.Errorf("%s must be grpc or json, got %q: %w", envTransport, transport, errInvalidArgument)
+	}
+
+	maxRetries, err := envInt(envMaxRetries, -1)
+	if err != nil {
+		return ec, err
+	}
+	if maxRetries >= 0 {
+		ec.cfg.maxAttempts = int(maxRetries) + 1
+	}
+
+	timeoutMs, err := envInt(envTimeoutMs, 0)
+	if err != nil {
+		return ec, err
+	}
+	if timeoutMs < 0 {
+		return ec, fmt.Errorf("%s must not be negative: %w", envTimeoutMs, errInvalidArgument)
+	}
+	ec.timeout = time.Duration(timeoutMs) * time.Millisecond
+
+	ec.cfg.endpoint = os.Getenv(envServiceEndpoint)
+	ec.cfg.quotaProject = os.Getenv(envProjectID)
+	ec.cfg.credentialsFile = os.Getenv(envCredentialsFile)
+	return ec, nil
+}
+
+// GoStorageInitFromEnvironment is like GoStorageInit, but reads its
+// configuration from environment variables, for deployments that can't pass
+// engine options:
+//
+//   - STORAGEWRAPPER_IODEPTH: the iodepth, 1 if unset.
+//   - STORAGEWRAPPER_TRANSPORT: "grpc", the default, or "json" to read over
+//     JSON as with GoStorageInitWithJSONReadFallback.
+//   - STORAGEWRAPPER_MAX_RETRIES: how many times to retry an operation, in
+//     place of the client's default.
+//   - STORAGEWRAPPER_TIMEOUT_MS: the default read timeout, as with
+//     GoStorageSetOperationTimeout.
+//   - STORAGEWRAPPER_LOG_LEVEL: the log level, e.g. "INFO", for the whole
+//     process.
+//   - STORAGEWRAPPER_SERVICE_ENDPOINT: an endpoint override.
+//   - STORAGEWRAPPER_PROJECT_ID: the project billed for requests.
+//   - STORAGEWRAPPER_CREDENTIALS_FILE: a service account key file to use in
+//     place of application default credentials.
+//
+//export GoStorageInitFromEnvironment
+func GoStorageInitFromEnvironment() uintptr {
+	if s := os.Getenv(envLogLevel); s != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(s)); err != nil {
+			slog.Error("invalid log level",
+				"name", envLogLevel,
+				"err", err,
+			)
+			return 0
+		}
+		slog.SetLogLoggerLevel(level)
+	}
+	ec, err := readEnvConfig()
+	if err != nil {
+		slog.Error("invalid environment configuration", "err", err)
+		return 0
+	}
+	slog.Info("go storage init from environment",
+		"iodepth", ec.iodepth,
+		"json_reads", ec.cfg.jsonReads,
+		"max_attempts", ec.cfg.maxAttempts,
+		"timeout", ec.timeout,
+		"endpoint", ec.cfg.endpoint,
+		"project_id", ec.cfg.quotaProject,
+		"credentials_file", ec.cfg.credentialsFile,
+	)
+
+	td := initThreadData(ec.iodepth, ec.cfg, false)
+	if td == 0 {
+		return 0
+	}
+	t, _, _ := handle[*threadData](td)
+	t.timeout.Store(int64(ec.timeout))
+	return td
+}
@@ -0,0 +1,61 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+/*
+#include <stdlib.h>
+
+// Called by GoStorage with the iou of each operation that fails, a
+// GoStorageError code, a description of the error, and the user data passed to
+// GoStorageSetErrorCallback. message is only valid during the call.
+typedef void (*GoStorageErrorCallback)(void* iou, int code, const char* message, void* user_data);
+*/
+import "C"
+
+import (
+	"log/slog"
+	"unsafe"
+)
+
+// errorCallback is a C function to notify of failed operations.
+type errorCallback struct {
+	fn       unsafe.Pointer
+	userData unsafe.Pointer
+}
+
+// notifyError calls t's error callback, if any, for iou failing with err.
+func (t *threadData) notifyError(iou unsafe.Pointer, err error) {
+	cb := t.errorCallback.Load()
+	if cb == nil {
+		return
+	}
+	msg := C.CString(err.Error())
+	defer C.free(unsafe.Pointer(msg))
+	callErrorCallback(cb, iou, errorCodeOf(err), msg)
+}
+
+// GoStorageSetErrorCallback registers fn, a GoStorageErrorCallback, to be
+// called as soon as an operation on td fails, before its completion can be
+// reaped. fn runs on an arbitrary thread, possibly concurrently with itself,
+// and must not block. A NULL fn removes the callback. Returns 0 on success and
+// a negative error code on error.
+//
+//export GoStorageSetErrorCallback
+func GoStorageSetErrorCallback(td uintptr, fn, userData unsafe.Pointer) int {
+	slog.Debug("go storage set error callback", "td", td)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set error callback: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if fn == nil {
+		t.errorCallback.Store(nil)
+		return 0
+	}
+	t.errorCallback.Store(&errorCallback{fn: fn, userData: userData})
+	return 0
+}
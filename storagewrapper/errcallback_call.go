@@ -0,0 +1,28 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+// Go can't call C function pointers directly, so this file holds the C side of
+// errcallback.go. Files with exports may only declare C functions, so it has
+// none.
+
+/*
+#include <stdlib.h>
+
+typedef void (*GoStorageErrorCallback)(void* iou, int code, const char* message, void* user_data);
+
+static void call_error_callback(void* fn, void* iou, int code, const char* message, void* user_data) {
+	((GoStorageErrorCallback)fn)(iou, code, message, user_data);
+}
+*/
+import "C"
+
+import "unsafe"
+
+func callErrorCallback(cb *errorCallback, iou unsafe.Pointer, code errorCode, msg *C.char) {
+	C.call_error_callback(cb.fn, iou, C.int(code), msg, cb.userData)
+}
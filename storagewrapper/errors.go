@@ -0,0 +1,173 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+/*
+// Error codes returned by GoStorage functions that return an int status.
+// GO_STORAGE_ERR_UNKNOWN is returned when no more specific code applies, so
+// callers should treat any negative result as an error rather than checking
+// for -1. GoStorageGetErrorString describes each code.
+typedef enum {
+	GO_STORAGE_ERR_UNKNOWN = -1,
+	GO_STORAGE_ERR_BAD_HANDLE = -2,
+	GO_STORAGE_ERR_INVALID_ARGUMENT = -3,
+	GO_STORAGE_ERR_BUFFER_TOO_SMALL = -4,
+	GO_STORAGE_ERR_NOT_FOUND = -5,
+	GO_STORAGE_ERR_PERMISSION_DENIED = -6,
+	GO_STORAGE_ERR_QUOTA_EXCEEDED = -7,
+	GO_STORAGE_ERR_CHECKSUM_MISMATCH = -8,
+	GO_STORAGE_ERR_CANCELLED = -9,
+	GO_STORAGE_ERR_DEADLINE_EXCEEDED = -10,
+	GO_STORAGE_ERR_TRANSPORT = -11,
+	GO_STORAGE_ERR_EXPIRED = -12,
+	GO_STORAGE_ERR_PRECONDITION_FAILED = -13,
+} GoStorageError;
+*/
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorCode is a negative status returned to C, mirroring GoStorageError.
+type errorCode int
+
+const (
+	codeUnknown            errorCode = C.GO_STORAGE_ERR_UNKNOWN
+	codeBadHandle          errorCode = C.GO_STORAGE_ERR_BAD_HANDLE
+	codeInvalidArgument    errorCode = C.GO_STORAGE_ERR_INVALID_ARGUMENT
+	codeBufferTooSmall     errorCode = C.GO_STORAGE_ERR_BUFFER_TOO_SMALL
+	codeNotFound           errorCode = C.GO_STORAGE_ERR_NOT_FOUND
+	codePermissionDenied   errorCode = C.GO_STORAGE_ERR_PERMISSION_DENIED
+	codeQuotaExceeded      errorCode = C.GO_STORAGE_ERR_QUOTA_EXCEEDED
+	codeChecksumMismatch   errorCode = C.GO_STORAGE_ERR_CHECKSUM_MISMATCH
+	codeCancelled          errorCode = C.GO_STORAGE_ERR_CANCELLED
+	codeDeadlineExceeded   errorCode = C.GO_STORAGE_ERR_DEADLINE_EXCEEDED
+	codeTransport          errorCode = C.GO_STORAGE_ERR_TRANSPORT
+	codeExpired            errorCode = C.GO_STORAGE_ERR_EXPIRED
+	codePreconditionFailed errorCode = C.GO_STORAGE_ERR_PRECONDITION_FAILED
+)
+
+var errorCodeStrings = map[errorCode]string{
+	codeUnknown:            "unknown error",
+	codeBadHandle:          "invalid or wrong type handle",
+	codeInvalidArgument:    "invalid argument",
+	codeBufferTooSmall:     "buffer too small",
+	codeNotFound:           "object or bucket not found",
+	codePermissionDenied:   "permission denied",
+	codeQuotaExceeded:      "quota or rate limit exceeded",
+	codeChecksumMismatch:   "checksum mismatch",
+	codeCancelled:          "operation cancelled",
+	codeDeadlineExceeded:   "deadline exceeded",
+	codeTransport:          "transport error",
+	codeExpired:            "signed URL expired",
+	codePreconditionFailed: "precondition failed",
+}
+
+func (c errorCode) String() string {
+	if s, ok := errorCodeStrings[c]; ok {
+		return s
+	}
+	return fmt.Sprintf("unrecognized error code %d", int(c))
+}
+
+// gRPC status codes that errorCodeOf maps to error codes.
+var grpcErrorCodes = map[codes.Code]errorCode{
+	codes.NotFound:           codeNotFound,
+	codes.Unauthenticated:    codePermissionDenied,
+	codes.PermissionDenied:   codePermissionDenied,
+	codes.ResourceExhausted:  codeQuotaExceeded,
+	codes.DataLoss:           codeChecksumMismatch,
+	codes.Canceled:           codeCancelled,
+	codes.DeadlineExceeded:   codeDeadlineExceeded,
+	codes.Unavailable:        codeTransport,
+	codes.FailedPrecondition: codePreconditionFailed,
+}
+
+// Errors that errorCodeOf maps to codes of their own, for errors raised before
+// any request is made.
+var (
+	errBadHandle          = errors.New("wrong type handle")
+	errInvalidArgument    = errors.New("invalid argument")
+	errPermissionDenied   = errors.New("permission denied")
+	errExpired            = errors.New("expired")
+	errPreconditionFailed = errors.New("precondition failed")
+	errChecksumMismatch   = errors.New("checksum mismatch")
+)
+
+// errorCodeOf classifies err, which must be non-nil.
+func errorCodeOf(err error) errorCode {
+	switch {
+	case errors.Is(err, errBadHandle):
+		return codeBadHandle
+	case errors.Is(err, errInvalidArgument):
+		return codeInvalidArgument
+	case errors.Is(err, errPermissionDenied):
+		return codePermissionDenied
+	case errors.Is(err, errExpired):
+		return codeExpired
+	case errors.Is(err, errPreconditionFailed):
+		return codePreconditionFailed
+	case errors.Is(err, errChecksumMismatch):
+		return codeChecksumMismatch
+	case errors.Is(err, storage.ErrObjectNotExist), errors.Is(err, storage.ErrBucketNotExist):
+		return codeNotFound
+	case errors.Is(err, context.Canceled):
+		return codeCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codeDeadlineExceeded
+	}
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusNotFound:
+			return codeNotFound
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return codePermissionDenied
+		case http.StatusTooManyRequests:
+			return codeQuotaExceeded
+		case http.StatusPreconditionFailed:
+			return codePreconditionFailed
+		}
+	}
+	if s, ok := status.FromError(err); ok {
+		if code, ok := grpcErrorCodes[s.Code()]; ok {
+			return code
+		}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return codeTransport
+	}
+	// The client reports CRC mismatches on read only in the error text.
+	if strings.Contains(err.Error(), "bad CRC") {
+		return codeChecksumMismatch
+	}
+	return codeUnknown
+}
+
+// GoStorageGetErrorString writes a description of error code code, as
+// returned by other GoStorage functions, to buf. Returns the number of bytes
+// written, excluding the terminator, or GO_STORAGE_ERR_BUFFER_TOO_SMALL.
+//
+//export GoStorageGetErrorString
+func GoStorageGetErrorString(code C.int, buf *C.char, bufLen C.int) int {
+	slog.Debug("go storage get error string", "code", code)
+	return writeCString(buf, bufLen, errorCode(code).String())
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"fmt"
+	"log/slog"
+	"unsafe"
+
+	"cloud.google.com/go/storage"
+)
+
+// GoStorageGetObjectETag writes the NUL-terminated ETag of the object backing
+// read file v into buf, from its cached attributes, without a network call.
+// The ETag is only known once attributes have been fetched, e.g. by
+// GoStorageFetchAttributes; opening a file doesn't fetch it. Returns the
+// length written, excluding the terminator, 0 if the ETag isn't known or the
+// attributes have expired, and a negative error code on error, including if
+// buf is too small.
+//
+//export GoStorageGetObjectETag
+func GoStorageGetObjectETag(v uintptr, buf *C.char, bufLen C.int) int {
+	slog.Debug("go storage get object etag", "handle", v)
+	f, _, ok := handle[infoFile](v)
+	if !ok {
+		slog.Error("get object etag: wrong type handle", "v", v)
+		return int(codeBadHandle)
+	}
+	attrs := f.info().cachedAttrs()
+	if attrs == nil || attrs.Etag == "" {
+		return 0
+	}
+	n := writeCString(buf, bufLen, attrs.Etag)
+	if n < 0 {
+		slog.Error("get object etag: buffer too small", "buf_len", bufLen)
+	}
+	return n
+}
+
+// GoStorageQueueIfETagMatch is like GoStorageQueue for read file v, but the
+// read fails with GO_STORAGE_ERR_PRECONDITION_FAILED unless the object still
+// has ETag etag. GCS doesn't accept ETags as read preconditions, so the ETag is
+// checked against the file's cached attributes, fetching them if necessary,
+// and the read is made conditional on the generation and metageneration those
+// attributes describe, which together determine the ETag. The read uses its
+// own range reader on td, whatever v's read strategy. Returns 1 if queued, and
+// a negative error code on error.
+//
+//export GoStorageQueueIfETagMatch
+func GoStorageQueueIfETagMatch(td, v uintptr, iou unsafe.Pointer, offset int64, b unsafe.Pointer, bl C.int, etagCstr *C.char) int {
+	etag := C.GoString(etagCstr)
+	slog.Debug("go storage queue if etag match",
+		"td", td,
+		"handle", v,
+		"etag", etag,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("queue if etag match: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	f, _, ok := handle[infoFile](v)
+	if !ok {
+		slog.Error("queue if etag match: wrong type handle", "v", v)
+		return int(codeBadHandle)
+	}
+	if etag == "" {
+		slog.Error("queue if etag match: empty etag")
+		return int(codeInvalidArgument)
+	}
+	if res := checkQueue("queue if etag match", f, offset, b, bl); res < 0 {
+		return res
+	}
+
+	info := f.info()
+	attrs, err := info.loadAttrs()
+	if err == nil && attrs.Etag == "" {
+		// Attributes cached when opening have no ETag.
+		attrs, err = info.fetchAttrs()
+	}
+	if err != nil {
+		slog.Error("queue if etag match: fetch attributes failed", "err", err)
+		return int(errorCodeOf(err))
+	}
+	if attrs.Etag != etag {
+		err := fmt.Errorf("etag %q does not match %q: %w", etag, attrs.Etag, errPreconditionFailed)
+		slog.Error("queue if etag match: etag mismatch", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	oh := info.oh.If(storage.Conditions{
+		GenerationMatch:     attrs.Generation,
+		MetagenerationMatch: attrs.Metageneration,
+	})
+	r := &rangeReaderFile{
+		objectInfo: newObjectInfo(t, oh),
+		t:          t,
+	}
+	return trackInflight(v, iou, offset, int64(bl), func() int {
+		return r.enqueue(C.GoBytes(b, bl), offset, iou)
+	})
+}
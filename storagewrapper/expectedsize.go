@@ -0,0 +1,94 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"unsafe"
+
+	"cloud.google.com/go/storage"
+)
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n atomic.Int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// addExpectingSize adds a read of len(p) bytes at offset to mrd, completing
+// with an error wrapping io.ErrUnexpectedEOF if fewer bytes arrive.
+func addExpectingSize(mrd *storage.MultiRangeDownloader, p []byte, offset int64, complete func(error)) {
+	w := &countingWriter{w: bytes.NewBuffer(p)}
+	want := int64(len(p))
+	mrd.Add(w, offset, want, func(offset, length int64, err error) {
+		if got := w.n.Load(); err == nil && got != want {
+			err = fmt.Errorf("read %d bytes at offset %d, want %d: %w", got, offset, want, io.ErrUnexpectedEOF)
+		}
+		complete(err)
+	})
+}
+
+// sizeCheckedFile is implemented by files that can check how many bytes a
+// read returned.
+type sizeCheckedFile interface {
+	enqueueExpectingSize(p []byte, offset int64, tag unsafe.Pointer) int
+}
+
+func (m *mrdFile) enqueueExpectingSize(p []byte, offset int64, tag unsafe.Pointer) int {
+	if err := m.t.checkRange(offset, int64(len(p))); err != nil {
+		slog.Error("enqueue: invalid range", "err", err)
+		return int(errorCodeOf(err))
+	}
+	addExpectingSize(m.mrd, p, offset, m.t.completeOnce(tag, m.t.opTimeout(m.timeout)))
+	return fioQQueued
+}
+
+func (m *multiStreamMrdFile) enqueueExpectingSize(p []byte, offset int64, tag unsafe.Pointer) int {
+	if err := m.t.checkRange(offset, int64(len(p))); err != nil {
+		slog.Error("enqueue: invalid range", "err", err)
+		return int(errorCodeOf(err))
+	}
+	mrd := m.mrds[(m.next.Add(1)-1)%uint64(len(m.mrds))]
+	addExpectingSize(mrd, p, offset, m.t.completeOnce(tag, m.t.opTimeout(m.timeout)))
+	return fioQQueued
+}
+
+// GoStorageQueueWithExpectedSize is like GoStorageQueue, but a read that
+// returns fewer than bl bytes, e.g. past the end of the object, completes with
+// an error instead of leaving the rest of the buffer unfilled. Range reader
+// files always check this. O_DIRECT files are queued as with GoStorageQueue,
+// without the check.
+//
+//export GoStorageQueueWithExpectedSize
+func GoStorageQueueWithExpectedSize(v uintptr, iou unsafe.Pointer, offset int64, b unsafe.Pointer, bl C.int) int {
+	slog.Debug("go storage queue with expected size",
+		"handle", v,
+		"expected_size", bl,
+	)
+	f, _, ok := handle[sizeCheckedFile](v)
+	if !ok {
+		return queue(v, iou, offset, b, bl)
+	}
+	if res := checkQueue("queue with expected size", f, offset, b, bl); res < 0 {
+		return res
+	}
+	return trackInflight(v, iou, offset, int64(bl), func() int {
+		return f.enqueueExpectingSize(C.GoBytes(b, bl), offset, iou)
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+/*
+#include <unistd.h>
+*/
+import "C"
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"log/slog"
+	"runtime/cgo"
+	"slices"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// handleInfo describes a live handle, for finding leaks.
+type handleInfo struct {
+	seq     uint64
+	typ     string
+	created time.Time
+	// If non-nil, the thread the handle's file was opened on.
+	thread *threadData
+}
+
+var (
+	handlesMu sync.Mutex
+	handleSeq uint64
+	// Every handle returned to C that hasn't been deleted.
+	liveHandles = make(map[cgo.Handle]handleInfo)
+)
+
+// newHandle returns a handle to v for C, recording it until deleteHandle.
+func newHandle(v any) uintptr {
+	return newThreadHandle(nil, v)
+}
+
+// newThreadHandle is like newHandle, but for a file opened on t, which counts
+// the file as open until deleteHandle.
+func newThreadHandle(t *threadData, v any) uintptr {
+	h := cgo.NewHandle(v)
+	handlesMu.Lock()
+	defer handlesMu.Unlock()
+	handleSeq++
+	liveHandles[h] = handleInfo{
+		seq:     handleSeq,
+		typ:     fmt.Sprintf("%T", v),
+		created: time.Now(),
+		thread:  t,
+	}
+	if t != nil {
+		t.openFiles.Add(1)
+	}
+	return uintptr(h)
+}
+
+// deleteHandle deletes h, which must have come from newHandle or
+// newThreadHandle.
+func deleteHandle(h cgo.Handle) {
+	handlesMu.Lock()
+	info := liveHandles[h]
+	delete(liveHandles, h)
+	handlesMu.Unlock()
+	if info.thread != nil {
+		info.thread.openFiles.Add(-1)
+	}
+	h.Delete()
+}
+
+// writeFD writes b to file descriptor fd, which is left open.
+func writeFD(fd C.int, b []byte) error {
+	for len(b) > 0 {
+		n := C.write(fd, unsafe.Pointer(&b[0]), C.size_t(len(b)))
+		if n < 0 {
+			return fmt.Errorf("writing to fd %d failed", fd)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// GoStorageDumpHandleRegistry writes every live handle, oldest first, to file
+// descriptor fd, one per line, e.g.
+//
+//	[3] type=*main.mrdFile handle=7 created=2026-01-02T15:04:05Z age=12s
+//
+// so that test harnesses can find handles that were never closed or cleaned
+// up. fd is left open. Returns the number of handles written, and a negative
+// error code on error.
+//
+//export GoStorageDumpHandleRegistry
+func GoStorageDumpHandleRegistry(fd C.int) int {
+	slog.Debug("go storage dump handle registry", "fd", fd)
+	type entry struct {
+		handleInfo
+		h cgo.Handle
+	}
+	handlesMu.Lock()
+	entries := make([]entry, 0, len(liveHandles))
+	for h, info := range liveHandles {
+		entries = append(entries, entry{info, h})
+	}
+	handlesMu.Unlock()
+	slices.SortFunc(entries, func(a, b entry) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+
+	var buf bytes.Buffer
+	now := time.Now()
+	for _, e := range entries {
+		fmt.Fprintf(&buf, "[%d] type=%s handle=%d created=%s age=%ds\n",
+			e.seq,
+			e.typ,
+			uintptr(e.h),
+			e.created.Format(time.RFC3339),
+			int64(now.Sub(e.created).Seconds()),
+		)
+	}
+	if err := writeFD(fd, buf.Bytes()); err != nil {
+		slog.Error("dump handle registry: write failed", "err", err)
+		return int(codeUnknown)
+	}
+	return len(entries)
+}
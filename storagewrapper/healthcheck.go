@@ -0,0 +1,166 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	// Registers the client side of the gRPC health checking protocol.
+	_ "google.golang.org/grpc/health"
+)
+
+// The number of consecutive failed health checks after which the connection
+// is reported unhealthy.
+const maxHealthCheckFailures = 3
+
+// runHealthCheck probes t's connection, by looking up bucket, every interval
+// until t's context is done, counting the probes that have failed in a row,
+// and logging when more than maxHealthCheckFailures have and when the
+// connection recovers.
+func (t *threadData) runHealthCheck(interval time.Duration, bucket string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(t.ctx, interval)
+		// Hold the client, so that reconfigure waits for the probe rather
+		// than closing the client under it.
+		t.clientMu.RLock()
+		err := probeConnection(ctx, t.client, bucket)
+		t.clientMu.RUnlock()
+		cancel()
+		if err == nil {
+			slog.Debug("health check passed")
+			if prev := t.healthCheckFailures.Swap(0); prev > maxHealthCheckFailures {
+				slog.Info("health check passed, connection recovered", "consecutive_failures", prev)
+			}
+			continue
+		}
+		if t.ctx.Err() != nil {
+			return
+		}
+		failures := t.healthCheckFailures.Add(1)
+		slog.Warn("health check failed",
+			"consecutive_failures", failures,
+			"err", err,
+		)
+		if failures == maxHealthCheckFailures+1 {
+			slog.Error("too many failed health checks, connection unhealthy",
+				"consecutive_failures", failures,
+			)
+		}
+	}
+}
+
+// GoStorageEnableHealthCheck checks every intervalMs milliseconds that td's
+// client can still reach GCS, by looking up bucket, which should be a bucket
+// the benchmark uses, or td's default bucket if bucket is NULL or empty, so
+// that a silently dropped connection is noticed before the next request needs
+// it. Each failed check is logged, and after more than 3 consecutive failures
+// the connection is logged as unhealthy; GoStorageGetHealthCheckFailures
+// reports the count. Operations aren't affected, and checking continues, so
+// that recovery is noticed too. Checking stops when td is cleaned up. Enabling
+// the check again has no effect. Returns 0 on success and a negative error
+// code on error.
+//
+//export GoStorageEnableHealthCheck
+func GoStorageEnableHealthCheck(td uintptr, bucketCstr *C.char, intervalMs int64) int {
+	bucket := C.GoString(bucketCstr)
+	interval := time.Duration(intervalMs) * time.Millisecond
+	slog.Debug("go storage enable health check",
+		"td", td,
+		"bucket", bucket,
+		"interval", interval,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("enable health check: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if interval <= 0 {
+		slog.Error("enable health check: non-positive interval", "interval", interval)
+		return int(codeInvalidArgument)
+	}
+	if bucket == "" {
+		bucket = t.defaultBucket
+	}
+	if bucket == "" {
+		slog.Error("enable health check: no bucket or default bucket to probe")
+		return int(codeInvalidArgument)
+	}
+	if t.healthCheck.Swap(true) {
+		return 0
+	}
+	go t.runHealthCheck(interval, bucket)
+	return 0
+}
+
+// GoStorageGetHealthCheckFailures returns how many of td's health checks, from
+// GoStorageEnableHealthCheck, have failed in a row, 0 once one passes. More
+// than 3 means the connection is unhealthy. Returns a negative error code on
+// error.
+//
+//export GoStorageGetHealthCheckFailures
+func GoStorageGetHealthCheckFailures(td uintptr) int {
+	slog.Debug("go storage get health check failures", "td", td)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("get health check failures: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	return int(t.healthCheckFailures.Load())
+}
+
+// GoStorageEnableGRPCHealthCheck makes td's client check the health of each
+// backend it connects to with the standard gRPC health checking protocol for
+// serviceName, e.g. "google.storage.v2.Storage", and stop sending requests to
+// backends reported unhealthy. This suits traffic routed through a proxy such
+// as Envoy that answers health checks. gRPC runs the checks itself, on each
+// connection, and only with load balancing policies that support them, such as
+// round_robin from GoStorageInitWithLBPolicy. The client is rebuilt, which
+// fails with GO_STORAGE_ERR_INVALID_ARGUMENT while files are open or
+// operations are in flight on td. Returns 0 on success and a negative error
+// code on error.
+//
+//export GoStorageEnableGRPCHealthCheck
+func GoStorageEnableGRPCHealthCheck(td uintptr, serviceNameCstr *C.char) int {
+	serviceName := C.GoString(serviceNameCstr)
+	slog.Debug("go storage enable grpc health check",
+		"td", td,
+		"service_name", serviceName,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("enable grpc health check: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if serviceName == "" {
+		slog.Error("enable grpc health check: empty service name")
+		return int(codeInvalidArgument)
+	}
+
+	prev := t.config()
+	cfg := prev
+	cfg.healthCheckService = serviceName
+	if cfg == prev {
+		return 0
+	}
+	if err := t.reconfigure(cfg); err != nil {
+		slog.Error("enable grpc health check: failed to rebuild client", "err", err)
+		return int(errorCodeOf(err))
+	}
+	return 0
+}
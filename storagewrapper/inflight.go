@@ -0,0 +1,85 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// inflightIO describes a queued operation that hasn't completed yet.
+type inflightIO struct {
+	// The file the operation was queued on.
+	v uintptr
+	// For reads, the object read from, and the offset and number of bytes
+	// requested.
+	bucket string
+	object string
+	offset int64
+	n      int64
+	// When the operation was queued.
+	queued time.Time
+}
+
+// inflightIOs maps the iou of each queued operation that hasn't completed yet
+// to its inflightIO.
+var inflightIOs sync.Map
+
+// trackInflight calls enqueue, which queues iou to transfer n bytes at offset
+// on file v, recording iou as in flight until it completes.
+func trackInflight(v uintptr, iou unsafe.Pointer, offset, n int64, enqueue func() int) int {
+	if iou == nil {
+		return enqueue()
+	}
+	op := inflightIO{v: v, offset: offset, n: n, queued: time.Now()}
+	if f, _, ok := handle[infoFile](v); ok {
+		op.bucket = f.info().oh.BucketName()
+		op.object = f.info().oh.ObjectName()
+	}
+	inflightIOs.Store(iou, op)
+	res := enqueue()
+	if res != fioQQueued {
+		inflightIOs.Delete(iou)
+	}
+	return res
+}
+
+// GoStorageGetInflightIOUs writes to ious the iou of each operation queued on
+// file v that hasn't completed yet, up to maxCount of them. Callers can reap
+// these before closing v, so that no read is left writing to a freed buffer.
+// v may already be closed. Returns the number written, and a negative error
+// code on error.
+//
+//export GoStorageGetInflightIOUs
+func GoStorageGetInflightIOUs(v uintptr, ious *unsafe.Pointer, maxCount C.int) int {
+	slog.Debug("go storage get inflight ious",
+		"handle", v,
+		"max_count", maxCount,
+	)
+	if maxCount < 0 || (ious == nil && maxCount > 0) {
+		slog.Error("get inflight ious: invalid buffer", "max_count", maxCount)
+		return int(codeInvalidArgument)
+	}
+
+	buf := unsafe.Slice(ious, int(maxCount))
+	n := 0
+	inflightIOs.Range(func(iou, op any) bool {
+		if n == len(buf) {
+			return false
+		}
+		if op.(inflightIO).v == v {
+			buf[n] = iou.(unsafe.Pointer)
+			n++
+		}
+		return true
+	})
+	return n
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+/*
+#include <stdlib.h>
+
+// Called by GoStorage after each unary gRPC call with the full method name,
+// the serialized request and response protobufs, and the user data passed to
+// GoStorageSetUnaryInterceptor. The response is empty if the call failed. All
+// are only valid during the call. May be called from any thread, possibly
+// concurrently with itself.
+typedef void (*GoStorageUnaryInterceptor)(const char* method, const void* request, int request_len, const void* response, int response_len, void* user_data);
+*/
+import "C"
+
+import (
+	"context"
+	"log/slog"
+	"unsafe"
+
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// marshalMessage serializes m, or returns nil if it isn't a protobuf message
+// or can't be serialized.
+func marshalMessage(m any) []byte {
+	pm, ok := m.(proto.Message)
+	if !ok {
+		return nil
+	}
+	b, err := proto.Marshal(pm)
+	if err != nil {
+		slog.Warn("unary interceptor: failed to serialize message", "err", err)
+		return nil
+	}
+	return b
+}
+
+// unaryInterceptorOption returns an option passing every unary gRPC call to
+// fn, a GoStorageUnaryInterceptor, along with userData.
+func unaryInterceptorOption(fn, userData unsafe.Pointer) option.ClientOption {
+	unary := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		var resp []byte
+		if err == nil {
+			resp = marshalMessage(reply)
+		}
+		cmethod := C.CString(method)
+		defer C.free(unsafe.Pointer(cmethod))
+		callUnaryInterceptor(fn, cmethod, marshalMessage(req), resp, userData)
+		return err
+	}
+	return option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(unary))
+}
+
+// GoStorageSetUnaryInterceptor passes every unary gRPC call td's client makes,
+// such as metadata requests, to interceptorFn, a GoStorageUnaryInterceptor,
+// along with userData, e.g. for tracing. Reads and writes stream, so they
+// aren't passed. A NULL interceptorFn removes the interceptor. The client is
+// rebuilt, which fails with GO_STORAGE_ERR_INVALID_ARGUMENT while files are
+// open or operations are in flight on td. Returns 0 on success and a negative
+// error code on error.
+//
+//export GoStorageSetUnaryInterceptor
+func GoStorageSetUnaryInterceptor(td uintptr, interceptorFn, userData unsafe.Pointer) int {
+	slog.Debug("go storage set unary interceptor",
+		"td", td,
+		"set", interceptorFn != nil,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set unary interceptor: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+
+	prev := t.config()
+	cfg := prev
+	cfg.unaryInterceptor = interceptorFn
+	cfg.unaryInterceptorData = userData
+	if interceptorFn == nil {
+		cfg.unaryInterceptorData = nil
+	}
+	if cfg == prev {
+		return 0
+	}
+	if err := t.reconfigure(cfg); err != nil {
+		slog.Error("set unary interceptor: failed to rebuild client", "err", err)
+		return int(errorCodeOf(err))
+	}
+	return 0
+}
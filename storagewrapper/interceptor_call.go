@@ -0,0 +1,31 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+// Go can't call C function pointers directly, so this file holds the C side of
+// interceptor.go. Files with exports may only declare C functions, so it has
+// none.
+
+/*
+typedef void (*GoStorageUnaryInterceptor)(const char* method, const void* request, int request_len, const void* response, int response_len, void* user_data);
+
+static void call_unary_interceptor(void* fn, const char* method, const void* request, int request_len, const void* response, int response_len, void* user_data) {
+	((GoStorageUnaryInterceptor)fn)(method, request, request_len, response, response_len, user_data);
+}
+*/
+import "C"
+
+import "unsafe"
+
+// callUnaryInterceptor calls interceptor fn. req and resp are passed to C
+// directly, which cgo allows since they hold no Go pointers.
+func callUnaryInterceptor(fn unsafe.Pointer, method *C.char, req, resp []byte, userData unsafe.Pointer) {
+	C.call_unary_interceptor(fn, method,
+		unsafe.Pointer(unsafe.SliceData(req)), C.int(len(req)),
+		unsafe.Pointer(unsafe.SliceData(resp)), C.int(len(resp)),
+		userData)
+}
@@ -0,0 +1,173 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// fioJob is one job section of a fio job file, with the options of the
+// [global] sections before it applied beneath its own.
+type fioJob struct {
+	name    string
+	options map[string]string
+}
+
+// iodepth returns the job's iodepth, which fio defaults to 1.
+func (j fioJob) iodepth() (uint, error) {
+	s, ok := j.options["iodepth"]
+	if !ok {
+		return 1, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 0)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("job %v: invalid iodepth %q: %w", j.name, s, errInvalidArgument)
+	}
+	return uint(n), nil
+}
+
+// numjobs returns how many clones of the job fio runs, which it defaults to 1.
+func (j fioJob) numjobs() (int, error) {
+	s, ok := j.options["numjobs"]
+	if !ok {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("job %v: invalid numjobs %q: %w", j.name, s, errInvalidArgument)
+	}
+	return n, nil
+}
+
+// parseFioJobFile parses the INI-style fio job file in r into its job
+// sections, in order. As in fio, a [global] section only sets defaults for
+// the job sections after it. Options are "key=value" or a bare "key", and
+// lines starting with ';' or '#' are comments.
+func parseFioJobFile(r io.Reader) ([]fioJob, error) {
+	global := make(map[string]string)
+	var jobs []fioJob
+	var section map[string]string
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			name, ok := strings.CutSuffix(line[1:], "]")
+			if !ok || name == "" {
+				return nil, fmt.Errorf("line %d: malformed section header %q: %w", lineNum, line, errInvalidArgument)
+			}
+			if name == "global" {
+				section = global
+				continue
+			}
+			jobs = append(jobs, fioJob{name: name, options: maps.Clone(global)})
+			section = jobs[len(jobs)-1].options
+			continue
+		}
+		if section == nil {
+			return nil, fmt.Errorf("line %d: option outside any section: %w", lineNum, errInvalidArgument)
+		}
+		key, value, _ := strings.Cut(line, "=")
+		section[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading job file: %w", err)
+	}
+	return jobs, nil
+}
+
+// GoStorageSetupFromFioJobFile parses the fio job file at jobFilePath and
+// initializes a thread, as with GoStorageInit with default options, for each
+// of the numjobs clones of each job section, using its iodepth, for Go-native
+// drivers that run fio job files without fio. The handles are written to tds
+// in job order, with each job's clones together, and tds must have room for
+// maxThreads of them. Each job's rw, bs and filename are logged for the
+// driver's reference but don't affect the threads. Returns the number of
+// threads initialized, and a negative error code on error, in which case none
+// are left initialized.
+//
+//export GoStorageSetupFromFioJobFile
+func GoStorageSetupFromFioJobFile(jobFilePathCstr *C.char, tds *uintptr, maxThreads C.int) int {
+	jobFilePath := C.GoString(jobFilePathCstr)
+	slog.Info("go storage setup from fio job file",
+		"job_file_path", jobFilePath,
+		"max_threads", maxThreads,
+	)
+	if tds == nil || maxThreads < 0 {
+		slog.Error("setup from fio job file: invalid thread buffer")
+		return int(codeInvalidArgument)
+	}
+	f, err := os.Open(jobFilePath)
+	if err != nil {
+		slog.Error("setup from fio job file: failed to open job file", "err", err)
+		return int(codeInvalidArgument)
+	}
+	defer f.Close()
+	jobs, err := parseFioJobFile(f)
+	if err != nil {
+		slog.Error("setup from fio job file: failed to parse job file", "err", err)
+		return int(errorCodeOf(err))
+	}
+	iodepths := make([]uint, len(jobs))
+	numjobs := make([]int, len(jobs))
+	threads := 0
+	for i, j := range jobs {
+		if iodepths[i], err = j.iodepth(); err != nil {
+			slog.Error("setup from fio job file: invalid job", "err", err)
+			return int(errorCodeOf(err))
+		}
+		if numjobs[i], err = j.numjobs(); err != nil {
+			slog.Error("setup from fio job file: invalid job", "err", err)
+			return int(errorCodeOf(err))
+		}
+		threads += numjobs[i]
+	}
+	if threads > int(maxThreads) {
+		slog.Error("setup from fio job file: too many threads",
+			"threads", threads,
+			"max_threads", maxThreads,
+		)
+		return int(codeBufferTooSmall)
+	}
+
+	out := unsafe.Slice(tds, int(maxThreads))
+	n := 0
+	for i, j := range jobs {
+		slog.Info("setup from fio job file: initializing job",
+			"job", j.name,
+			"iodepth", iodepths[i],
+			"numjobs", numjobs[i],
+			"rw", j.options["rw"],
+			"bs", j.options["bs"],
+			"filename", j.options["filename"],
+		)
+		for range numjobs[i] {
+			td := initThreadData(iodepths[i], clientConfig{}, false)
+			if td == 0 {
+				for _, prev := range out[:n] {
+					GoStorageCleanup(prev)
+				}
+				return int(codeUnknown)
+			}
+			out[n] = td
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,188 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFioJobFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		jobFile string
+		want    []fioJob
+		wantErr bool
+	}{
+		{
+			name: "global before job",
+			jobFile: `[global]
+rw=randread
+bs=4k
+[job1]
+bs=1m
+`,
+			want: []fioJob{
+				{name: "job1", options: map[string]string{"rw": "randread", "bs": "1m"}},
+			},
+		},
+		{
+			name: "global after job",
+			jobFile: `[job1]
+iodepth=8
+[global]
+rw=read
+[job2]
+`,
+			want: []fioJob{
+				{name: "job1", options: map[string]string{"iodepth": "8"}},
+				{name: "job2", options: map[string]string{"rw": "read"}},
+			},
+		},
+		{
+			name: "global sections accumulate",
+			jobFile: `[global]
+rw=read
+[job1]
+[global]
+bs=4k
+[job2]
+`,
+			want: []fioJob{
+				{name: "job1", options: map[string]string{"rw": "read"}},
+				{name: "job2", options: map[string]string{"rw": "read", "bs": "4k"}},
+			},
+		},
+		{
+			name: "bare keys",
+			jobFile: `[job1]
+direct
+ time_based
+`,
+			want: []fioJob{
+				{name: "job1", options: map[string]string{"direct": "", "time_based": ""}},
+			},
+		},
+		{
+			name: "comments and blank lines",
+			jobFile: `; leading comment
+[job1]
+
+# rw=write
+rw = read
+  ; indented comment
+`,
+			want: []fioJob{
+				{name: "job1", options: map[string]string{"rw": "read"}},
+			},
+		},
+		{
+			name:    "global only",
+			jobFile: "[global]\nrw=read\n",
+		},
+		{
+			name:    "option outside section",
+			jobFile: "rw=read\n[job1]\n",
+			wantErr: true,
+		},
+		{
+			name:    "malformed section header",
+			jobFile: "[job1\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty section name",
+			jobFile: "[]\n",
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseFioJobFile(strings.NewReader(tc.jobFile))
+			if tc.wantErr {
+				if !errors.Is(err, errInvalidArgument) {
+					t.Fatalf("parseFioJobFile() error = %v, want %v", err, errInvalidArgument)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFioJobFile() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parseFioJobFile() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFioJobIodepth(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]string
+		want    uint
+		wantErr bool
+	}{
+		{name: "missing", options: map[string]string{}, want: 1},
+		{name: "set", options: map[string]string{"iodepth": "64"}, want: 64},
+		{name: "zero", options: map[string]string{"iodepth": "0"}, wantErr: true},
+		{name: "negative", options: map[string]string{"iodepth": "-1"}, wantErr: true},
+		{name: "not a number", options: map[string]string{"iodepth": "deep"}, wantErr: true},
+		{name: "bare", options: map[string]string{"iodepth": ""}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := fioJob{name: "job", options: tc.options}.iodepth()
+			if tc.wantErr {
+				if !errors.Is(err, errInvalidArgument) {
+					t.Fatalf("iodepth() error = %v, want %v", err, errInvalidArgument)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("iodepth() error = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("iodepth() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFioJobNumjobs(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]string
+		want    int
+		wantErr bool
+	}{
+		{name: "missing", options: map[string]string{}, want: 1},
+		{name: "set", options: map[string]string{"numjobs": "4"}, want: 4},
+		{name: "zero", options: map[string]string{"numjobs": "0"}, wantErr: true},
+		{name: "negative", options: map[string]string{"numjobs": "-2"}, wantErr: true},
+		{name: "not a number", options: map[string]string{"numjobs": "many"}, wantErr: true},
+		{name: "bare", options: map[string]string{"numjobs": ""}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := fioJob{name: "job", options: tc.options}.numjobs()
+			if tc.wantErr {
+				if !errors.Is(err, errInvalidArgument) {
+					t.Fatalf("numjobs() error = %v, want %v", err, errInvalidArgument)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("numjobs() error = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("numjobs() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"slices"
+
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// GCS records headers with this prefix in Cloud Audit Logs, which makes them
+// usable for attributing requests to jobs.
+const customAuditHeaderPrefix = "x-goog-custom-audit-"
+
+// validLabel reports whether key and value can be sent as a custom audit
+// header: gRPC only accepts metadata keys of lowercase letters, digits, '-',
+// '_' and '.', and values of printable ASCII.
+func validLabel(key, value string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '_' && c != '.' {
+			return false
+		}
+	}
+	for _, c := range value {
+		if c < ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestLabelOptions returns client options that attach labels, a URL query
+// encoded set of labels, to every gRPC call as custom audit headers.
+func requestLabelOptions(labels string) []option.ClientOption {
+	values, err := url.ParseQuery(labels)
+	if err != nil {
+		// Labels are validated before they get here.
+		slog.Error("invalid request labels", "err", err)
+		return nil
+	}
+	var kv []string
+	for k, vs := range values {
+		for _, v := range vs {
+			kv = append(kv, customAuditHeaderPrefix+k, v)
+		}
+	}
+	withLabels := func(ctx context.Context, method string) context.Context {
+		slog.Debug("attaching request labels",
+			"method", method,
+			"labels", labels,
+		)
+		return metadata.AppendToOutgoingContext(ctx, kv...)
+	}
+	unary := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(withLabels(ctx, method), method, req, reply, cc, opts...)
+	}
+	stream := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(withLabels(ctx, method), desc, cc, method, opts...)
+	}
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(unary)),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(stream)),
+	}
+}
+
+// GoStorageSetRequestLabels attaches labels to every request td makes, so
+// that GCS usage can be attributed per job. labels is URL query encoded, e.g.
+// "job=nightly&team=storage", and each label is sent as an
+// x-goog-custom-audit-<key> header, which is recorded in Cloud Audit Logs.
+// Keys may only hold lowercase letters, digits, '-', '_' and '.', and values
+// only printable ASCII, as gRPC requires. Malformed and invalid labels are
+// logged and dropped. The client is rebuilt, which fails with
+// GO_STORAGE_ERR_INVALID_ARGUMENT while files are open or operations are in
+// flight on td. Returns 0 on success and a negative error code on error.
+//
+//export GoStorageSetRequestLabels
+func GoStorageSetRequestLabels(td uintptr, labelsCstr *C.char) int {
+	labels := C.GoString(labelsCstr)
+	slog.Debug("go storage set request labels",
+		"td", td,
+		"labels", labels,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set request labels: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	values, err := url.ParseQuery(labels)
+	if err != nil {
+		slog.Warn("ignoring malformed request labels",
+			"labels", labels,
+			"err", err,
+		)
+		values = nil
+	}
+	for k, vs := range values {
+		valid := slices.DeleteFunc(vs, func(v string) bool {
+			if validLabel(k, v) {
+				return false
+			}
+			slog.Warn("ignoring invalid request label",
+				"key", k,
+				"value", v,
+			)
+			return true
+		})
+		if len(valid) == 0 {
+			delete(values, k)
+		} else {
+			values[k] = valid
+		}
+	}
+
+	prev := t.config()
+	cfg := prev
+	cfg.requestLabels = values.Encode()
+	if cfg == prev {
+		return 0
+	}
+	if err := t.reconfigure(cfg); err != nil {
+		slog.Error("set request labels: failed to rebuild client", "err", err)
+		return int(errorCodeOf(err))
+	}
+	return 0
+}
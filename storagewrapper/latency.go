@@ -0,0 +1,147 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+/*
+// Round-trip latencies measured by GoStorageBenchmarkLatency, in milliseconds.
+typedef struct {
+	double p50_ms;
+	double p95_ms;
+	double p99_ms;
+	double min_ms;
+	double max_ms;
+	double mean_ms;
+} GoStorageLatencyResults;
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// latencySummary summarizes latency samples.
+type latencySummary struct {
+	p50, p95, p99, minimum, maximum, mean time.Duration
+}
+
+// summarizeLatencies summarizes samples, which must not be empty, sorting them
+// in place. Percentiles use the nearest-rank method.
+func summarizeLatencies(samples []time.Duration) latencySummary {
+	slices.Sort(samples)
+	percentile := func(p float64) time.Duration {
+		return samples[max(int(math.Ceil(p*float64(len(samples))))-1, 0)]
+	}
+	var total time.Duration
+	for _, s := range samples {
+		total += s
+	}
+	return latencySummary{
+		p50:     percentile(0.50),
+		p95:     percentile(0.95),
+		p99:     percentile(0.99),
+		minimum: samples[0],
+		maximum: samples[len(samples)-1],
+		mean:    total / time.Duration(len(samples)),
+	}
+}
+
+// readFirstByte times reading the first byte of oh, giving up after timeout if
+// it is positive.
+func readFirstByte(ctx context.Context, oh *storage.ObjectHandle, timeout time.Duration) (time.Duration, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	rr, err := oh.NewRangeReader(ctx, 0, 1)
+	if err != nil {
+		return 0, fmt.Errorf("opening range reader: %w", err)
+	}
+	_, err = io.Copy(io.Discard, rr)
+	if closeErr := rr.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading first byte: %w", err)
+	}
+	return time.Since(start), nil
+}
+
+func durationMs(d time.Duration) C.double {
+	return C.double(float64(d) / float64(time.Millisecond))
+}
+
+// GoStorageBenchmarkLatency measures the round-trip latency to GCS before a
+// benchmark, by reading the first byte of bucket/object samples times in a row
+// and timing each read. The percentiles, minimum, maximum and mean latency are
+// logged and, if results is non-NULL, written to *results. Blocks until every
+// sample is taken. Returns 0 on success and a negative error code on error.
+//
+//export GoStorageBenchmarkLatency
+func GoStorageBenchmarkLatency(td uintptr, bucketCstr, objectCstr *C.char, samples C.int, results *C.GoStorageLatencyResults) int {
+	bucket := C.GoString(bucketCstr)
+	object := C.GoString(objectCstr)
+	slog.Debug("go storage benchmark latency",
+		"td", td,
+		"bucket", bucket,
+		"object", object,
+		"samples", samples,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("benchmark latency: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if samples <= 0 {
+		slog.Error("benchmark latency: invalid sample count", "samples", samples)
+		return int(codeInvalidArgument)
+	}
+
+	oh := t.bucket(bucket).Object(object)
+	latencies := make([]time.Duration, 0, int(samples))
+	for range int(samples) {
+		d, err := readFirstByte(t.ctx, oh, t.opTimeout(nil))
+		if err != nil {
+			slog.Error("benchmark latency: sample failed", "err", err)
+			return int(errorCodeOf(err))
+		}
+		latencies = append(latencies, d)
+	}
+
+	s := summarizeLatencies(latencies)
+	slog.Info("gcs round-trip latency",
+		"bucket", bucket,
+		"object", object,
+		"samples", samples,
+		"p50", s.p50,
+		"p95", s.p95,
+		"p99", s.p99,
+		"min", s.minimum,
+		"max", s.maximum,
+		"mean", s.mean,
+	)
+	if results != nil {
+		*results = C.GoStorageLatencyResults{
+			p50_ms:  durationMs(s.p50),
+			p95_ms:  durationMs(s.p95),
+			p99_ms:  durationMs(s.p99),
+			min_ms:  durationMs(s.minimum),
+			max_ms:  durationMs(s.maximum),
+			mean_ms: durationMs(s.mean),
+		}
+	}
+	return 0
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"golang.org/x/time/rate"
+)
+
+// checkRange returns an error if reading length bytes at offset would go past
+// t's max object size.
+func (t *threadData) checkRange(offset, length int64) error {
+	if t.maxObjectSize > 0 && offset+length > t.maxObjectSize {
+		return fmt.Errorf("range [%d, %d) exceeds max object size %d: %w", offset, offset+length, t.maxObjectSize, errInvalidArgument)
+	}
+	return nil
+}
+
+// GoStorageSetMaxObjectSize guards td against unexpectedly large objects:
+// opening a larger object fails, as does queueing a read that extends past
+// maxBytes. A maxBytes of 0 removes the limit. Objects opened with O_DIRECT
+// have no size at open, so only their reads are checked. Returns 0 on success
+// and a negative error code on error.
+//
+//export GoStorageSetMaxObjectSize
+func GoStorageSetMaxObjectSize(td uintptr, maxBytes int64) int {
+	slog.Debug("go storage set max object size",
+		"td", td,
+		"max_bytes", maxBytes,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set max object size: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if maxBytes < 0 {
+		slog.Error("set max object size: negative size", "max_bytes", maxBytes)
+		return int(codeInvalidArgument)
+	}
+	t.maxObjectSize = maxBytes
+	return 0
+}
+
+// waitBandwidth blocks until o's bandwidth limit, if any, allows reading n
+// bytes.
+func (o *objectInfo) waitBandwidth(ctx context.Context, n int) error {
+	o.limiterMu.Lock()
+	limiter := o.limiter
+	o.limiterMu.Unlock()
+	if limiter == nil {
+		return nil
+	}
+	// WaitN rejects requests larger than the burst, so wait in pieces.
+	for n > 0 {
+		chunk := min(n, limiter.Burst())
+		if err := limiter.WaitN(ctx, chunk); err != nil {
+			return fmt.Errorf("waiting for bandwidth: %w", err)
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+// GoStorageSetFileBandwidthLimit limits reads from file v to bytesPerSecond,
+// e.g. to hold reads of a cold object to a fraction of what hot objects get.
+// Queueing a read blocks until the limit allows it. A bytesPerSecond of 0
+// removes the limit. Returns 0 on success and a negative error code on error.
+//
+//export GoStorageSetFileBandwidthLimit
+func GoStorageSetFileBandwidthLimit(v uintptr, bytesPerSecond int64) int {
+	slog.Debug("go storage set file bandwidth limit",
+		"handle", v,
+		"bytes_per_second", bytesPerSecond,
+	)
+	f, _, ok := handle[infoFile](v)
+	if !ok {
+		slog.Error("set file bandwidth limit: wrong type handle", "v", v)
+		return int(codeBadHandle)
+	}
+	if bytesPerSecond < 0 {
+		slog.Error("set file bandwidth limit: negative limit", "bytes_per_second", bytesPerSecond)
+		return int(codeInvalidArgument)
+	}
+	var limiter *rate.Limiter
+	if bytesPerSecond > 0 {
+		// A burst of one second's worth lets reads of up to that size through
+		// in one wait.
+		limiter = rate.NewLimiter(rate.Limit(bytesPerSecond), int(min(bytesPerSecond, math.MaxInt32)))
+	}
+	info := f.info()
+	info.limiterMu.Lock()
+	defer info.limiterMu.Unlock()
+	info.limiter = limiter
+	return 0
+}
@@ -0,0 +1,182 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+/*
+#include <stdint.h>
+#include <stdlib.h>
+
+// Called by GoStorageFetchObjectDetails with each object's name, size,
+// generation and update time, in nanoseconds since the Unix epoch, and the
+// user data passed to it. name is only valid during the call.
+typedef void (*GoStorageObjectDetailsCallback)(const char* name, int64_t size, int64_t generation, int64_t updated_unix_ns, void* user_data);
+*/
+import "C"
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"unsafe"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// An objectListing is a listing started by GoStorageListStart.
+type objectListing struct {
+	it *storage.ObjectIterator
+	// The entry GoStorageListNext couldn't return because the name buffer
+	// was too small, returned again by the next call.
+	pending *storage.ObjectAttrs
+}
+
+// next returns the pending entry, if any, or the iterator's next one.
+func (l *objectListing) next() (*storage.ObjectAttrs, error) {
+	if attrs := l.pending; attrs != nil {
+		l.pending = nil
+		return attrs, nil
+	}
+	return l.it.Next()
+}
+
+// GoStorageListStart starts listing the objects in bucket whose names begin
+// with prefix. If delimiter is non-empty, names are grouped as in a directory
+// listing. Returns a handle for GoStorageListNext, or 0 on error; the handle
+// must be released with GoStorageListClose.
+//
+//export GoStorageListStart
+func GoStorageListStart(td uintptr, bucketCstr, prefixCstr, delimiterCstr *C.char) uintptr {
+	bucket := C.GoString(bucketCstr)
+	query := &storage.Query{
+		Prefix:    C.GoString(prefixCstr),
+		Delimiter: C.GoString(delimiterCstr),
+	}
+	slog.Debug("go storage list start",
+		"td", td,
+		"bucket", bucket,
+		"prefix", query.Prefix,
+		"delimiter", query.Delimiter,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("list start: wrong type handle", "td", td)
+		return 0
+	}
+	if err := query.SetAttrSelection([]string{"Name", "Size"}); err != nil {
+		slog.Error("list start: failed to set attr selection", "err", err)
+		return 0
+	}
+	it := t.bucket(bucket).Objects(context.Background(), query)
+	return newThreadHandle(t, &objectListing{it: it})
+}
+
+// GoStorageListNext advances listing listHandle, writing the next name to
+// nameBuf and, if size is non-NULL, its size to *size. Prefixes produced by a
+// delimiter have size 0. Returns 1 for an entry, 0 once the listing is done,
+// and a negative error code on error. If the name doesn't fit in nameBuf,
+// GO_STORAGE_ERR_BUFFER_TOO_SMALL is returned and the entry isn't consumed, so
+// the next call, with a larger buffer, returns it again.
+//
+//export GoStorageListNext
+func GoStorageListNext(listHandle uintptr, nameBuf *C.char, nameBufLen C.int, size *int64) int {
+	l, _, ok := handle[*objectListing](listHandle)
+	if !ok {
+		slog.Error("list next: wrong type handle", "v", listHandle)
+		return int(codeBadHandle)
+	}
+	attrs, err := l.next()
+	if errors.Is(err, iterator.Done) {
+		return 0
+	}
+	if err != nil {
+		slog.Error("list next: failed", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	name := attrs.Name
+	if name == "" {
+		name = attrs.Prefix
+	}
+	if writeCString(nameBuf, nameBufLen, name) < 0 {
+		slog.Error("list next: name buffer too small",
+			"name", name,
+			"buf_len", nameBufLen,
+		)
+		l.pending = attrs
+		return int(codeBufferTooSmall)
+	}
+	if size != nil {
+		*size = attrs.Size
+	}
+	return 1
+}
+
+// GoStorageListClose releases listing listHandle. Returns 0 on success and a
+// negative error code if listHandle is not a listing.
+//
+//export GoStorageListClose
+func GoStorageListClose(listHandle uintptr) int {
+	_, h, ok := handle[*objectListing](listHandle)
+	if !ok {
+		slog.Error("list close: wrong type handle", "v", listHandle)
+		return int(codeBadHandle)
+	}
+	deleteHandle(h)
+	return 0
+}
+
+// GoStorageFetchObjectDetails lists the objects in bucket whose names begin
+// with prefix, calling callback, a GoStorageObjectDetailsCallback, with each
+// one's name, size, generation and update time along with userData. The
+// details come from the listing itself, so preflight checks need no request
+// per object. At most maxObjects are listed, or all of them if maxObjects is
+// 0. Returns the number of objects listed, and a negative error code on error,
+// after which callback may already have been called.
+//
+//export GoStorageFetchObjectDetails
+func GoStorageFetchObjectDetails(td uintptr, bucketCstr, prefixCstr *C.char, maxObjects C.int, callback, userData unsafe.Pointer) int {
+	bucket := C.GoString(bucketCstr)
+	query := &storage.Query{Prefix: C.GoString(prefixCstr)}
+	slog.Debug("go storage fetch object details",
+		"td", td,
+		"bucket", bucket,
+		"prefix", query.Prefix,
+		"max_objects", maxObjects,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("fetch object details: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if callback == nil || maxObjects < 0 {
+		slog.Error("fetch object details: invalid argument", "max_objects", maxObjects)
+		return int(codeInvalidArgument)
+	}
+	if err := query.SetAttrSelection([]string{"Name", "Size", "Generation", "Updated"}); err != nil {
+		slog.Error("fetch object details: failed to set attr selection", "err", err)
+		return int(codeUnknown)
+	}
+
+	it := t.bucket(bucket).Objects(context.Background(), query)
+	n := 0
+	for maxObjects == 0 || n < int(maxObjects) {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			slog.Error("fetch object details: listing failed", "err", err)
+			return int(errorCodeOf(err))
+		}
+		name := C.CString(attrs.Name)
+		callObjectDetailsCallback(callback, name, attrs, userData)
+		C.free(unsafe.Pointer(name))
+		n++
+	}
+	return n
+}
@@ -0,0 +1,32 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+// Go can't call C function pointers directly, so this file holds the C side of
+// GoStorageFetchObjectDetails in list.go. Files with exports may only declare C
+// functions, so it has none.
+
+/*
+#include <stdint.h>
+
+typedef void (*GoStorageObjectDetailsCallback)(const char* name, int64_t size, int64_t generation, int64_t updated_unix_ns, void* user_data);
+
+static void call_object_details_callback(void* fn, const char* name, int64_t size, int64_t generation, int64_t updated_unix_ns, void* user_data) {
+	((GoStorageObjectDetailsCallback)fn)(name, size, generation, updated_unix_ns, user_data);
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"cloud.google.com/go/storage"
+)
+
+func callObjectDetailsCallback(fn unsafe.Pointer, name *C.char, attrs *storage.ObjectAttrs, userData unsafe.Pointer) {
+	C.call_object_details_callback(fn, name, C.int64_t(attrs.Size), C.int64_t(attrs.Generation), C.int64_t(attrs.Updated.UnixNano()), userData)
+}
@@ -0,0 +1,167 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// Metadata keys of a lock object, holding the lease that owns the lock and
+// when it expires.
+const (
+	lockLeaseIDKey = "lease-id"
+	lockExpiresKey = "lease-expires"
+)
+
+// lockObject returns the lock object guarding oh.
+func (t *threadData) lockObject(oh *storage.ObjectHandle) *storage.ObjectHandle {
+	return t.bucket(oh.BucketName()).Object(oh.ObjectName() + ".lock")
+}
+
+// writeLock writes lock object oh, under conds, as held by leaseID until
+// expires.
+func writeLock(ctx context.Context, oh *storage.ObjectHandle, conds storage.Conditions, leaseID string, expires time.Time) error {
+	w := oh.If(conds).NewWriter(ctx)
+	w.Metadata = map[string]string{
+		lockLeaseIDKey: leaseID,
+		lockExpiresKey: expires.UTC().Format(time.RFC3339Nano),
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("writing lock %v: %w", oh.ObjectName(), err)
+	}
+	return nil
+}
+
+// takeOverLock takes lock object oh, which already exists, for leaseID until
+// expires if its lease has expired. Returns whether the lock was taken.
+func takeOverLock(ctx context.Context, oh *storage.ObjectHandle, leaseID string, expires time.Time) (bool, error) {
+	attrs, err := oh.Attrs(ctx)
+	if err != nil {
+		return false, fmt.Errorf("reading lock %v: %w", oh.ObjectName(), err)
+	}
+	held, err := time.Parse(time.RFC3339Nano, attrs.Metadata[lockExpiresKey])
+	if err == nil && time.Now().Before(held) {
+		return false, nil
+	}
+	// The lease expired, or is unreadable, so take it over unless another
+	// writer does first.
+	err = writeLock(ctx, oh, storage.Conditions{GenerationMatch: attrs.Generation}, leaseID, expires)
+	if err != nil && errorCodeOf(err) == codePreconditionFailed {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// acquireLock tries to take lock object oh for leaseID until expires, taking
+// it over if its lease has expired. Returns whether the lock was acquired.
+func acquireLock(ctx context.Context, oh *storage.ObjectHandle, leaseID string, expires time.Time) (bool, error) {
+	err := writeLock(ctx, oh, storage.Conditions{DoesNotExist: true}, leaseID, expires)
+	if err != nil {
+		if errorCodeOf(err) != codePreconditionFailed {
+			return false, err
+		}
+		if taken, err := takeOverLock(ctx, oh, leaseID, expires); !taken {
+			return false, err
+		}
+	}
+
+	// Check that the lock written is the one in place.
+	attrs, err := oh.Attrs(ctx)
+	if err != nil {
+		return false, fmt.Errorf("reading back lock %v: %w", oh.ObjectName(), err)
+	}
+	return attrs.Metadata[lockLeaseIDKey] == leaseID, nil
+}
+
+// GoStorageObjectLockExclusive takes an advisory lock on an object for
+// leaseDurationSeconds, so that concurrent benchmark writers can agree on a
+// single writer. The lock is an object named after the object with a ".lock"
+// suffix, created only if it doesn't exist, or taken over once its lease has
+// expired. The new lease's ID is written to leaseIDBuf for
+// GoStorageObjectUnlockExclusive. Returns 0 if the lock was acquired, 1 if
+// another lease holds it, and a negative error code on error.
+//
+//export GoStorageObjectLockExclusive
+func GoStorageObjectLockExclusive(td uintptr, filenameCstr *C.char, leaseDurationSeconds C.int, leaseIDBuf *C.char, leaseIDBufLen C.int) int {
+	filename := C.GoString(filenameCstr)
+	slog.Debug("go storage object lock exclusive",
+		"td", td,
+		"filename", filename,
+		"lease_duration_seconds", leaseDurationSeconds,
+	)
+	if leaseDurationSeconds <= 0 {
+		slog.Error("object lock exclusive: invalid lease duration", "lease_duration_seconds", leaseDurationSeconds)
+		return int(codeInvalidArgument)
+	}
+	t, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("object lock exclusive: error getting *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	leaseID := rand.Text()
+	if n := writeCString(leaseIDBuf, leaseIDBufLen, leaseID); n < 0 {
+		slog.Error("object lock exclusive: lease ID buffer too small", "lease_id_buf_len", leaseIDBufLen)
+		return n
+	}
+	expires := time.Now().Add(time.Duration(leaseDurationSeconds) * time.Second)
+	acquired, err := acquireLock(context.Background(), t.lockObject(oh), leaseID, expires)
+	if err != nil {
+		slog.Error("object lock exclusive: failed", "err", err)
+		return int(errorCodeOf(err))
+	}
+	if !acquired {
+		slog.Info("object already locked", "filename", filename)
+		return 1
+	}
+	return 0
+}
+
+// GoStorageObjectUnlockExclusive releases a lock taken with
+// GoStorageObjectLockExclusive, by deleting the lock object if leaseID still
+// holds it. Returns 0 on success, GO_STORAGE_ERR_PRECONDITION_FAILED if
+// another lease holds the lock, and another negative error code on error.
+//
+//export GoStorageObjectUnlockExclusive
+func GoStorageObjectUnlockExclusive(td uintptr, filenameCstr, leaseIDCstr *C.char) int {
+	filename := C.GoString(filenameCstr)
+	leaseID := C.GoString(leaseIDCstr)
+	slog.Debug("go storage object unlock exclusive",
+		"td", td,
+		"filename", filename,
+	)
+	t, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("object unlock exclusive: error getting *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	ctx := context.Background()
+	lock := t.lockObject(oh)
+	attrs, err := lock.Attrs(ctx)
+	if err != nil {
+		slog.Error("object unlock exclusive: failed to read lock", "err", err)
+		return int(errorCodeOf(err))
+	}
+	if attrs.Metadata[lockLeaseIDKey] != leaseID {
+		slog.Error("object unlock exclusive: lock held by another lease", "filename", filename)
+		return int(codePreconditionFailed)
+	}
+	if err := lock.If(storage.Conditions{GenerationMatch: attrs.Generation}).Delete(ctx); err != nil {
+		slog.Error("object unlock exclusive: failed to delete lock", "err", err)
+		return int(errorCodeOf(err))
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"cloud.google.com/go/storage"
+)
+
+// mrdOptionFuncs maps the option names GoStorageSetMRDOptionJSON accepts to the
+// MultiRangeDownloader options they set.
+var mrdOptionFuncs = map[string]func(int) storage.MRDOption{
+	"minConnections":      storage.WithMinConnections,
+	"maxConnections":      storage.WithMaxConnections,
+	"targetPendingRanges": storage.WithTargetPendingRanges,
+	"targetPendingBytes":  storage.WithTargetPendingBytes,
+}
+
+// parseMRDOptions parses a JSON object of MultiRangeDownloader options, in
+// name order, skipping unrecognized names.
+func parseMRDOptions(b []byte) ([]storage.MRDOption, error) {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, fmt.Errorf("parsing options: %w: %w", err, errInvalidArgument)
+	}
+	var opts []storage.MRDOption
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		newOpt, ok := mrdOptionFuncs[name]
+		if !ok {
+			slog.Warn("ignoring unrecognized MRD option", "option", name)
+			continue
+		}
+		var n int
+		if err := json.Unmarshal(values[name], &n); err != nil || n <= 0 {
+			return nil, fmt.Errorf("option %v must be a positive integer: %w", name, errInvalidArgument)
+		}
+		opts = append(opts, newOpt(n))
+	}
+	return opts, nil
+}
+
+// GoStorageSetMRDOptionJSON sets the options td's MultiRangeDownloaders are
+// created with from a JSON object such as {"maxConnections": 4}, so that new
+// downloader options don't each need a function of their own. The recognized
+// options, each a positive integer, are minConnections, maxConnections,
+// targetPendingRanges and targetPendingBytes; others are logged and ignored.
+// The options replace any set before, and apply to files opened from now on.
+// Returns 0 on success and a negative error code on error.
+//
+//export GoStorageSetMRDOptionJSON
+func GoStorageSetMRDOptionJSON(td uintptr, optionJSONCstr *C.char) int {
+	optionJSON := C.GoString(optionJSONCstr)
+	slog.Debug("go storage set mrd option json",
+		"td", td,
+		"option_json", optionJSON,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set mrd option json: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	opts, err := parseMRDOptions([]byte(optionJSON))
+	if err != nil {
+		slog.Error("set mrd option json: invalid options", "err", err)
+		return int(errorCodeOf(err))
+	}
+	t.mrdOptions = opts
+	return 0
+}
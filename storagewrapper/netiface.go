@@ -0,0 +1,122 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+)
+
+// How long finding the network interface may take.
+const networkInterfaceTimeout = 5 * time.Second
+
+// endpointHost returns the host GCS traffic goes to for endpoint, which may be
+// empty for the default, a host and port, or a URL.
+func endpointHost(endpoint string) string {
+	if endpoint == "" {
+		return gcsHost
+	}
+	if _, rest, ok := strings.Cut(endpoint, "://"); ok {
+		endpoint = rest
+	}
+	endpoint, _, _ = strings.Cut(endpoint, "/")
+	if host, _, err := net.SplitHostPort(endpoint); err == nil {
+		return host
+	}
+	return endpoint
+}
+
+// findNetworkInterface returns the name of the network interface the system
+// routes traffic to host over.
+func findNetworkInterface(ctx context.Context, host string) (string, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return "", fmt.Errorf("resolving %v: %w", host, err)
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("resolving %v: no addresses", host)
+	}
+	// Connecting a UDP socket picks the route, and so the local address,
+	// without sending anything.
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: addrs[0].IP, Port: 443})
+	if err != nil {
+		return "", fmt.Errorf("finding route to %v: %w", addrs[0].IP, err)
+	}
+	local := conn.LocalAddr().(*net.UDPAddr).IP
+	_ = conn.Close()
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", fmt.Errorf("listing interfaces: %w", err)
+	}
+	for _, iface := range ifaces {
+		ifaceAddrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range ifaceAddrs {
+			if n, ok := a.(*net.IPNet); ok && n.IP.Equal(local) {
+				return iface.Name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no interface has local address %v", local)
+}
+
+// networkInterface returns the name of the network interface t's GCS traffic
+// goes over, or "" if it can't be determined. The lookup is done once, for the
+// endpoint t was initialized with.
+func (t *threadData) networkInterface() string {
+	t.netIfaceOnce.Do(func() {
+		host := t.netIfaceHost
+		ctx, cancel := context.WithTimeout(context.Background(), networkInterfaceTimeout)
+		defer cancel()
+		name, err := findNetworkInterface(ctx, host)
+		if err != nil {
+			slog.Warn("could not determine network interface",
+				"host", host,
+				"err", err,
+			)
+			return
+		}
+		slog.Info("gcs traffic network interface",
+			"host", host,
+			"interface", name,
+		)
+		t.netIface = name
+	})
+	return t.netIface
+}
+
+// GoStorageGetNetworkInterface writes to buf the name of the network interface
+// td's GCS traffic is routed over, to check the configuration of multi-homed
+// hosts. The interface is found by resolving the GCS host and finding the
+// interface that holds the local address the system routes to it from. The
+// interface is also logged when td is initialized. Returns the number of bytes
+// written, excluding the terminator, 0 if the interface can't be determined,
+// and a negative error code on error.
+//
+//export GoStorageGetNetworkInterface
+func GoStorageGetNetworkInterface(td uintptr, buf *C.char, bufLen C.int) int {
+	slog.Debug("go storage get network interface", "td", td)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("get network interface: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	name := t.networkInterface()
+	if name == "" {
+		return 0
+	}
+	return writeCString(buf, bufLen, name)
+}
@@ -0,0 +1,417 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unsafe"
+
+	"cloud.google.com/go/storage"
+)
+
+// retainedUntil returns when the retention on an object expires, combining the
+// bucket retention policy and any object retention configuration, or the zero
+// time if it isn't retained.
+func retainedUntil(attrs *storage.ObjectAttrs) time.Time {
+	until := attrs.RetentionExpirationTime
+	if attrs.Retention != nil && attrs.Retention.RetainUntil.After(until) {
+		until = attrs.Retention.RetainUntil
+	}
+	return until
+}
+
+// GoStorageObjectLockStatus reports whether an object is locked against being
+// overwritten, so write benchmarks can skip it rather than fail partway
+// through a run. Returns:
+//
+//   - 0 if the object is not locked,
+//   - 1 if it is locked, writing the Unix time the lock expires to
+//     *retainUntil (0 for an indefinite hold),
+//   - 2 if the object is not locked and its bucket has no retention
+//     configured at all,
+//   - a negative error code on error.
+//
+// Note that object retention is not reported over gRPC, so only bucket
+// retention policies and holds are detected.
+//
+//export GoStorageObjectLockStatus
+func GoStorageObjectLockStatus(td uintptr, filenameCstr *C.char, retainUntil *int64) int {
+	filename := C.GoString(filenameCstr)
+	slog.Debug("go storage object lock status",
+		"td", td,
+		"filename", filename,
+	)
+	t, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("lock status: error getting *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	attrs, err := oh.Attrs(context.Background())
+	if err != nil {
+		slog.Error("lock status: failed to get object attrs",
+			"filename", filename,
+			"err", err,
+		)
+		return int(errorCodeOf(err))
+	}
+	if attrs.EventBasedHold || attrs.TemporaryHold {
+		if retainUntil != nil {
+			*retainUntil = 0
+		}
+		return 1
+	}
+	if until := retainedUntil(attrs); until.After(time.Now()) {
+		if retainUntil != nil {
+			*retainUntil = until.Unix()
+		}
+		return 1
+	}
+
+	bucketAttrs, err := t.bucket(oh.BucketName()).Attrs(context.Background())
+	if err != nil {
+		slog.Error("lock status: failed to get bucket attrs",
+			"bucket", oh.BucketName(),
+			"err", err,
+		)
+		return int(errorCodeOf(err))
+	}
+	if bucketAttrs.RetentionPolicy == nil && bucketAttrs.ObjectRetentionMode == "" {
+		return 2
+	}
+	return 0
+}
+
+// GoStorageObjectExists reports whether an object exists, along with its
+// generation for use in conditional requests. Returns 1 if it exists, writing
+// its generation to *generation, 0 if it doesn't, writing 0, and a negative
+// error code on error.
+// generation may be NULL.
+//
+//export GoStorageObjectExists
+func GoStorageObjectExists(td uintptr, filenameCstr *C.char, generation *int64) int {
+	filename := C.GoString(filenameCstr)
+	slog.Debug("go storage object exists",
+		"td", td,
+		"filename", filename,
+	)
+	_, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("exists: error getting *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	attrs, err := oh.Attrs(context.Background())
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		if generation != nil {
+			*generation = 0
+		}
+		return 0
+	}
+	if err != nil {
+		slog.Error("exists: failed to get object attrs",
+			"filename", filename,
+			"err", err,
+		)
+		return int(errorCodeOf(err))
+	}
+	if generation != nil {
+		*generation = attrs.Generation
+	}
+	return 1
+}
+
+// GoStorageObjectTagsGet writes an object's custom metadata to buf, URL query
+// encoded, e.g. "status=ready". Returns the length written, or a negative
+// error code on error, including if buf is too small.
+//
+//export GoStorageObjectTagsGet
+func GoStorageObjectTagsGet(td uintptr, filenameCstr, buf *C.char, bufLen C.int) int {
+	filename := C.GoString(filenameCstr)
+	slog.Debug("go storage object tags get",
+		"td", td,
+		"filename", filename,
+	)
+	_, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("tags get: error getting *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	attrs, err := oh.Attrs(context.Background())
+	if err != nil {
+		slog.Error("tags get: failed to get object attrs",
+			"filename", filename,
+			"err", err,
+		)
+		return int(errorCodeOf(err))
+	}
+	values := url.Values{}
+	for k, v := range attrs.Metadata {
+		values.Set(k, v)
+	}
+	n := writeCString(buf, bufLen, values.Encode())
+	if n < 0 {
+		slog.Error("tags get: buffer too small", "buf_len", bufLen)
+	}
+	return n
+}
+
+// GoStorageObjectTagsSet sets the keys in tags, URL query encoded as returned
+// by GoStorageObjectTagsGet, in an object's custom metadata. GCS merges them
+// into the existing metadata, so keys not in tags are kept; empty tags instead
+// remove all custom metadata. Returns 0 on success and a negative error code
+// on error.
+//
+//export GoStorageObjectTagsSet
+func GoStorageObjectTagsSet(td uintptr, filenameCstr, tagsCstr *C.char) int {
+	filename := C.GoString(filenameCstr)
+	tags := C.GoString(tagsCstr)
+	slog.Debug("go storage object tags set",
+		"td", td,
+		"filename", filename,
+		"tags", tags,
+	)
+	values, err := url.ParseQuery(tags)
+	if err != nil {
+		slog.Error("tags set: malformed tags",
+			"tags", tags,
+			"err", err,
+		)
+		return int(errorCodeOf(err))
+	}
+	_, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("tags set: error getting *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	metadata := make(map[string]string, len(values))
+	for k := range values {
+		metadata[k] = values.Get(k)
+	}
+	if _, err := oh.Update(context.Background(), storage.ObjectAttrsToUpdate{Metadata: metadata}); err != nil {
+		slog.Error("tags set: failed to update object",
+			"filename", filename,
+			"err", err,
+		)
+		return int(errorCodeOf(err))
+	}
+	return 0
+}
+
+// GoStorageWaitForObject blocks until an object exists, checking every
+// pollIntervalMs milliseconds, for pipelines where another process writes the
+// object. Returns 0 once it exists, GO_STORAGE_ERR_DEADLINE_EXCEEDED if it
+// doesn't appear within timeoutMs milliseconds, and another negative error
+// code on any other error.
+//
+//export GoStorageWaitForObject
+func GoStorageWaitForObject(td uintptr, filenameCstr *C.char, pollIntervalMs, timeoutMs int64) int {
+	filename := C.GoString(filenameCstr)
+	pollInterval := time.Duration(pollIntervalMs) * time.Millisecond
+	timeout := time.Duration(timeoutMs) * time.Millisecond
+	slog.Debug("go storage wait for object",
+		"td", td,
+		"filename", filename,
+		"poll_interval", pollInterval,
+		"timeout", timeout,
+	)
+	if pollInterval <= 0 || timeout < 0 {
+		slog.Error("wait for object: invalid poll interval or timeout")
+		return int(codeInvalidArgument)
+	}
+	_, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("wait for object: error getting *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for attempt := 1; ; attempt++ {
+		slog.Debug("wait for object: polling",
+			"filename", filename,
+			"attempt", attempt,
+		)
+		_, err := oh.Attrs(ctx)
+		if err == nil {
+			return 0
+		}
+		if ctx.Err() != nil {
+			slog.Error("wait for object: timed out",
+				"filename", filename,
+				"timeout", timeout,
+			)
+			return int(codeDeadlineExceeded)
+		}
+		if !errors.Is(err, storage.ErrObjectNotExist) {
+			slog.Error("wait for object: failed to get object attrs",
+				"filename", filename,
+				"err", err,
+			)
+			return int(errorCodeOf(err))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			slog.Error("wait for object: timed out",
+				"filename", filename,
+				"timeout", timeout,
+			)
+			return int(codeDeadlineExceeded)
+		}
+	}
+}
+
+// GoStorageQueueDelete deletes an object asynchronously, completing on td with
+// iou like a read, so that deletes can be pipelined at the thread's iodepth.
+// Returns 1 if queued, and a negative error code on error.
+//
+//export GoStorageQueueDelete
+func GoStorageQueueDelete(td uintptr, filenameCstr *C.char, iou unsafe.Pointer) int {
+	filename := C.GoString(filenameCstr)
+	slog.Debug("go storage queue delete",
+		"td", td,
+		"filename", filename,
+		"iou", iou,
+	)
+	t, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("queue delete: error getting *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	done := t.beginClientOp()
+	t.spawn(func() {
+		defer done()
+		err := oh.Delete(context.Background())
+		if err != nil {
+			slog.Error("delete failed",
+				"filename", filename,
+				"err", err,
+			)
+			err = fmt.Errorf("deleting %v: %w", filename, err)
+		}
+		t.complete(iou, err)
+	})
+	return fioQQueued
+}
+
+// verifyCRC32C reads all of oh and checks that its CRC32C is expected.
+func verifyCRC32C(ctx context.Context, oh *storage.ObjectHandle, expected uint32) error {
+	r, err := oh.NewReader(ctx)
+	if err != nil {
+		return fmt.Errorf("opening reader: %w", err)
+	}
+	defer r.Close()
+	h := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	if _, err := io.Copy(h, r); err != nil {
+		return fmt.Errorf("reading object: %w", err)
+	}
+	if got := h.Sum32(); got != expected {
+		return fmt.Errorf("CRC32C %08x does not match expected %08x: %w", got, expected, errChecksumMismatch)
+	}
+	return nil
+}
+
+// GoStorageObjectIntegrityVerify reads back a whole object asynchronously and
+// checks its CRC32C against expectedCRC32C, e.g. to validate objects after the
+// write phase of a benchmark. iou completes on td like a read, with
+// GO_STORAGE_ERR_CHECKSUM_MISMATCH if the checksums differ. Returns 1 if
+// queued, and a negative error code on error.
+//
+//export GoStorageObjectIntegrityVerify
+func GoStorageObjectIntegrityVerify(td uintptr, filenameCstr *C.char, expectedCRC32C uint32, iou unsafe.Pointer) int {
+	filename := C.GoString(filenameCstr)
+	slog.Debug("go storage object integrity verify",
+		"td", td,
+		"filename", filename,
+		"expected_crc32c", expectedCRC32C,
+		"iou", iou,
+	)
+	t, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("object integrity verify: error getting *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	done := t.beginClientOp()
+	t.spawn(func() {
+		defer done()
+		err := verifyCRC32C(context.Background(), oh, expectedCRC32C)
+		if err != nil {
+			slog.Error("integrity verification failed",
+				"filename", filename,
+				"err", err,
+			)
+			err = fmt.Errorf("verifying %v: %w", filename, err)
+		}
+		t.complete(iou, err)
+	})
+	return fioQQueued
+}
+
+// Storage classes accepted by GoStorageObjectSetStorageClass.
+var storageClasses = []string{"STANDARD", "NEARLINE", "COLDLINE", "ARCHIVE"}
+
+// GoStorageObjectSetStorageClass changes an object's storage class, one of
+// "STANDARD", "NEARLINE", "COLDLINE" or "ARCHIVE" in any case, to model
+// lifecycle transitions. GCS changes the class by rewriting the object in
+// place, so this can take seconds for large objects, and gives the object a
+// new generation. Returns 0 on success and a negative error code on error.
+//
+//export GoStorageObjectSetStorageClass
+func GoStorageObjectSetStorageClass(td uintptr, filenameCstr, storageClassCstr *C.char) int {
+	filename := C.GoString(filenameCstr)
+	storageClass := strings.ToUpper(C.GoString(storageClassCstr))
+	slog.Debug("go storage object set storage class",
+		"td", td,
+		"filename", filename,
+		"storage_class", storageClass,
+	)
+	if !slices.Contains(storageClasses, storageClass) {
+		slog.Error("object set storage class: unsupported storage class", "storage_class", storageClass)
+		return int(codeInvalidArgument)
+	}
+	_, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("object set storage class: error getting *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	c := oh.CopierFrom(oh)
+	c.StorageClass = storageClass
+	attrs, err := c.Run(context.Background())
+	if err != nil {
+		slog.Error("object set storage class: rewrite failed",
+			"filename", filename,
+			"err", err,
+		)
+		return int(errorCodeOf(err))
+	}
+	slog.Info("object storage class changed",
+		"filename", filename,
+		"storage_class", attrs.StorageClass,
+		"generation", attrs.Generation,
+	)
+	return 0
+}
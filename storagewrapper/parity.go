@@ -0,0 +1,139 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// parityRead reads the same range from several objects and XORs the stripes
+// together.
+type parityRead struct {
+	t      *threadData
+	iou    unsafe.Pointer
+	out    []byte
+	stripe [][]byte
+	// Counts stripes not yet read.
+	remaining atomic.Int64
+
+	mu sync.Mutex
+	// The first stripe read error, if any.
+	err error
+}
+
+// readStripe reads stripe n from r at offset, and once every stripe is in,
+// XORs them into p.out and completes p.iou.
+func (p *parityRead) readStripe(r *rangeReaderFile, n int, offset int64) {
+	ctx := context.Background()
+	if timeout := p.t.opTimeout(nil); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := r.read(ctx, p.stripe[n], offset); err != nil {
+		p.mu.Lock()
+		if p.err == nil {
+			p.err = err
+		}
+		p.mu.Unlock()
+	}
+	if p.remaining.Add(-1) > 0 {
+		return
+	}
+
+	p.mu.Lock()
+	err := p.err
+	p.mu.Unlock()
+	if err == nil {
+		copy(p.out, p.stripe[0])
+		for _, s := range p.stripe[1:] {
+			for i := range p.out {
+				p.out[i] ^= s[i]
+			}
+		}
+	}
+	p.t.complete(p.iou, err)
+}
+
+// GoStorageQueueParity reads stripeSize bytes at offset from each of the
+// handleCount files in handles in parallel, and XORs the stripes together into
+// outBuf, as erasure-coding reconstruction does. iou completes on td once, after
+// every stripe is read and outBuf holds their parity; outBuf is left unchanged
+// if any read fails. The reads go through range readers, whatever the files'
+// read strategy. Returns 1 if queued, and a negative error code on error.
+//
+//export GoStorageQueueParity
+func GoStorageQueueParity(td uintptr, handles *uintptr, handleCount C.int, iou unsafe.Pointer, offset, stripeSize int64, outBuf unsafe.Pointer) int {
+	slog.Debug("go storage queue parity",
+		"td", td,
+		"handle_count", handleCount,
+		"offset", offset,
+		"stripe_size", stripeSize,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("queue parity: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if handles == nil || handleCount <= 0 || outBuf == nil {
+		slog.Error("queue parity: invalid handle or output buffer",
+			"handle_count", handleCount,
+		)
+		return int(codeInvalidArgument)
+	}
+	if offset < 0 || stripeSize <= 0 || stripeSize > math.MaxInt32 {
+		slog.Error("queue parity: invalid range",
+			"offset", offset,
+			"stripe_size", stripeSize,
+		)
+		return int(codeInvalidArgument)
+	}
+	if err := t.checkRange(offset, stripeSize); err != nil {
+		slog.Error("queue parity: invalid range", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	readers := make([]*rangeReaderFile, handleCount)
+	for i, v := range unsafe.Slice(handles, int(handleCount)) {
+		f, _, ok := handle[infoFile](v)
+		if !ok {
+			slog.Error("queue parity: wrong type handle", "v", v)
+			return int(codeBadHandle)
+		}
+		if res := checkQueue("queue parity", f, offset, outBuf, C.int(stripeSize)); res < 0 {
+			return res
+		}
+		readers[i] = &rangeReaderFile{
+			objectInfo: newObjectInfo(t, f.info().oh),
+			t:          t,
+		}
+	}
+
+	p := &parityRead{
+		t:      t,
+		iou:    iou,
+		out:    unsafe.Slice((*byte)(outBuf), int(stripeSize)),
+		stripe: make([][]byte, handleCount),
+	}
+	for i := range p.stripe {
+		p.stripe[i] = make([]byte, stripeSize)
+	}
+	p.remaining.Store(int64(handleCount))
+	for i, r := range readers {
+		t.spawn(func() {
+			p.readStripe(r, i, offset)
+		})
+	}
+	return fioQQueued
+}
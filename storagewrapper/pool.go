@@ -0,0 +1,220 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Most workers GoStorageSetWorkerPool starts for one thread.
+const maxWorkers = 1024
+
+// workQueue is a FIFO of tasks owned by one worker, which other workers steal
+// from when their own queue is empty.
+type workQueue struct {
+	mu    sync.Mutex
+	tasks []func()
+}
+
+func (q *workQueue) push(task func()) {
+	q.mu.Lock()
+	q.tasks = append(q.tasks, task)
+	q.mu.Unlock()
+}
+
+// pushFront adds task ahead of the others, for the owning worker to pop next.
+func (q *workQueue) pushFront(task func()) {
+	q.mu.Lock()
+	q.tasks = slices.Insert(q.tasks, 0, task)
+	q.mu.Unlock()
+}
+
+// pop removes the oldest task, for the owning worker.
+func (q *workQueue) pop() func() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.tasks) == 0 {
+		return nil
+	}
+	task := q.tasks[0]
+	q.tasks[0] = nil
+	q.tasks = q.tasks[1:]
+	return task
+}
+
+// steal removes the newest task, for other workers, so that it contends with
+// the owner only when one task is left.
+func (q *workQueue) steal() func() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.tasks) == 0 {
+		return nil
+	}
+	task := q.tasks[len(q.tasks)-1]
+	q.tasks[len(q.tasks)-1] = nil
+	q.tasks = q.tasks[:len(q.tasks)-1]
+	return task
+}
+
+// workerPool runs tasks on a fixed set of goroutines, avoiding a goroutine per
+// operation at high queue depths.
+type workerPool struct {
+	queues []*workQueue
+	next   atomic.Uint64
+	// Holds a token for each submitted task that may not have been picked up.
+	wake chan struct{}
+	// Closed once workers should stop when no tasks are left.
+	draining chan struct{}
+	// mu is held for reading while submitting, and for writing while
+	// draining, so that once stopped is set no task is queued.
+	mu      sync.RWMutex
+	stopped bool
+}
+
+func newWorkerPool(workers int) *workerPool {
+	p := &workerPool{
+		queues:   make([]*workQueue, workers),
+		wake:     make(chan struct{}, workers),
+		draining: make(chan struct{}),
+	}
+	for i := range p.queues {
+		p.queues[i] = &workQueue{}
+	}
+	for i := range workers {
+		go p.work(i)
+	}
+	return p
+}
+
+// submit queues task, ahead of the tasks already queued if first is set,
+// returning false without queueing it if the pool is draining or closed.
+func (p *workerPool) submit(task func(), first bool) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.stopped {
+		return false
+	}
+	q := p.queues[(p.next.Add(1)-1)%uint64(len(p.queues))]
+	if first {
+		q.pushFront(task)
+	} else {
+		q.push(task)
+	}
+	select {
+	case p.wake <- struct{}{}:
+	default:
+		// Every worker already has a wakeup pending, and will find the task.
+	}
+	return true
+}
+
+// take returns worker i's next task, stealing one if its queue is empty.
+func (p *workerPool) take(i int) func() {
+	if task := p.queues[i].pop(); task != nil {
+		return task
+	}
+	for j := 1; j < len(p.queues); j++ {
+		if task := p.queues[(i+j)%len(p.queues)].steal(); task != nil {
+			return task
+		}
+	}
+	return nil
+}
+
+func (p *workerPool) work(i int) {
+	for {
+		if task := p.take(i); task != nil {
+			task()
+			continue
+		}
+		select {
+		case <-p.draining:
+			// Nothing is queued once draining starts, so what is queued now
+			// is all that is left to run.
+			for task := p.take(i); task != nil; task = p.take(i) {
+				task()
+			}
+			return
+		case <-p.wake:
+		}
+	}
+}
+
+// drain stops the workers once every queued task has run, without waiting
+// for them. Later submits fail.
+func (p *workerPool) drain() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.draining)
+	}
+}
+
+// spawn runs f on t's worker pool if it has one, and on a new goroutine
+// otherwise.
+func (t *threadData) spawn(f func()) {
+	t.spawnQueued(f, false)
+}
+
+// spawnFirst is like spawn, but queues f ahead of the operations already
+// waiting on t's worker pool.
+func (t *threadData) spawnFirst(f func()) {
+	t.spawnQueued(f, true)
+}
+
+func (t *threadData) spawnQueued(f func(), first bool) {
+	for {
+		p := t.pool.Load()
+		if p == nil {
+			go f()
+			return
+		}
+		if p.submit(f, first) {
+			return
+		}
+		// p was replaced after it was loaded, and is draining, so submit to
+		// its replacement.
+	}
+}
+
+// GoStorageSetWorkerPool runs td's background operations, such as O_DIRECT
+// reads, on a pool of workers goroutines rather than a goroutine each, which
+// reduces scheduler overhead at queue depths in the hundreds. Each worker has
+// its own queue and steals from the others when it runs dry. Workers block
+// while their operation waits on GCS, so at most workers operations are in
+// flight at once; use at least as many workers as td's iodepth, or the pool
+// caps it. A workers of 0 removes the pool. Operations already queued on an
+// existing pool still run there, while later ones go to the new pool. Returns
+// 0 on success and a negative error code on error.
+//
+//export GoStorageSetWorkerPool
+func GoStorageSetWorkerPool(td uintptr, workers C.int) int {
+	slog.Debug("go storage set worker pool",
+		"td", td,
+		"workers", workers,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set worker pool: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if workers < 0 || workers > maxWorkers {
+		slog.Error("set worker pool: invalid worker count",
+			"workers", workers,
+			"max", maxWorkers,
+		)
+		return int(codeInvalidArgument)
+	}
+	t.resizeWorkerPool(int(workers))
+	return 0
+}
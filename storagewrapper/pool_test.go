@@ -0,0 +1,95 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Queue depths the spawn benchmarks keep in flight.
+var benchmarkIODepths = []int{64, 256, 1024}
+
+// benchmarkSpawn spawns b.N trivial operations on t, keeping up to iodepth of
+// them in flight, as fio does, so that the cost measured is scheduling.
+func benchmarkSpawn(b *testing.B, t *threadData, iodepth int) {
+	b.Helper()
+	inflight := make(chan struct{}, iodepth)
+	var wg sync.WaitGroup
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		inflight <- struct{}{}
+		wg.Add(1)
+		t.spawn(func() {
+			<-inflight
+			wg.Done()
+		})
+	}
+	wg.Wait()
+}
+
+func BenchmarkSpawnGoroutinePerOp(b *testing.B) {
+	for _, iodepth := range benchmarkIODepths {
+		b.Run(fmt.Sprintf("iodepth=%d", iodepth), func(b *testing.B) {
+			benchmarkSpawn(b, &threadData{}, iodepth)
+		})
+	}
+}
+
+func BenchmarkSpawnWorkerPool(b *testing.B) {
+	for _, iodepth := range benchmarkIODepths {
+		b.Run(fmt.Sprintf("iodepth=%d", iodepth), func(b *testing.B) {
+			t := &threadData{}
+			// These tasks don't block, so workers beyond one per CPU only
+			// contend for them. Operations that wait on GCS need a worker
+			// each.
+			t.resizeWorkerPool(runtime.GOMAXPROCS(0))
+			defer t.pool.Load().drain()
+			benchmarkSpawn(b, t, iodepth)
+		})
+	}
+}
+
+func TestResizeWorkerPoolRunsQueuedTasks(t *testing.T) {
+	td := &threadData{}
+	td.resizeWorkerPool(1)
+	block := make(chan struct{})
+	var wg sync.WaitGroup
+	var ran atomic.Int64
+	wg.Add(1)
+	td.spawn(func() {
+		<-block
+		ran.Add(1)
+		wg.Done()
+	})
+	// Queued behind the blocked task, so still queued when the pool drains.
+	const queued = 10
+	for range queued {
+		wg.Add(1)
+		td.spawn(func() {
+			ran.Add(1)
+			wg.Done()
+		})
+	}
+
+	td.resizeWorkerPool(2)
+	wg.Add(1)
+	td.spawn(func() {
+		ran.Add(1)
+		wg.Done()
+	})
+	close(block)
+	wg.Wait()
+	td.pool.Load().drain()
+	if got, want := ran.Load(), int64(queued+2); got != want {
+		t.Errorf("ran %d tasks, want %d", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"unsafe"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Header carrying the scheduling hint from GoStorageQueueWithPriority.
+const priorityHeader = "x-goog-request-priority"
+
+// Range of priorities accepted by GoStorageQueueWithPriority, and the lowest
+// that jumps the worker pool queue.
+const (
+	minPriority  = 0
+	maxPriority  = 9
+	highPriority = 5
+)
+
+// GoStorageQueueWithPriority is like GoStorageQueue, but attaches a scheduling
+// hint from 0 (lowest) to 9 (highest) to the read as the
+// x-goog-request-priority header, for proxies or future APIs that honour it.
+// GCS ignores the header today.
+//
+// The header is sent when a read opens its own stream, which is only the case
+// for O_DIRECT files. Those reads also run on the worker pool, if
+// GoStorageSetWorkerPool set one up, where reads of priority 5 and above are
+// queued ahead of those already waiting. Reads on other files share the file's
+// stream and don't go through the pool, so they are queued without the hint.
+// Returns GO_STORAGE_ERR_INVALID_ARGUMENT if priority is out of range.
+//
+//export GoStorageQueueWithPriority
+func GoStorageQueueWithPriority(v uintptr, iou unsafe.Pointer, offset int64, b unsafe.Pointer, bl, priority C.int) int {
+	slog.Debug("go storage queue with priority",
+		"handle", v,
+		"priority", priority,
+	)
+	if priority < minPriority || priority > maxPriority {
+		slog.Error("queue with priority: priority out of range",
+			"priority", priority,
+			"min", minPriority,
+			"max", maxPriority,
+		)
+		return int(codeInvalidArgument)
+	}
+	o, _, ok := handle[*oDirectMrdFile](v)
+	if !ok {
+		return queue(v, iou, offset, b, bl)
+	}
+	if res := checkQueue("queue with priority", o, offset, b, bl); res < 0 {
+		return res
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), priorityHeader, strconv.Itoa(int(priority)))
+	return trackInflight(v, iou, offset, int64(bl), func() int {
+		return o.enqueueContext(ctx, C.GoBytes(b, bl), offset, iou, priority >= highPriority)
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import "log/slog"
+
+// workloadProfile is a preset of thread settings for one kind of fio job.
+type workloadProfile struct {
+	readStrategy  string
+	readAheadSize int64
+	// Whether to run operations on a worker pool with a worker per operation
+	// the iodepth allows in flight.
+	pooled bool
+}
+
+// Profiles applied by GoStorageApplyWorkloadProfile. The values are starting
+// points reasoned from how each read path behaves, not measurements, and any
+// setting can be changed afterwards with its own call. Profiles don't set a
+// bandwidth limit, gRPC flow control or a cap on reads in flight: bandwidth is
+// limited per file, with GoStorageSetFileBandwidthLimit, gRPC buffers are fixed
+// when the client is built, by GoStorageInitWithBuffers, and reads in flight
+// are already bounded by the iodepth.
+var workloadProfiles = map[string]workloadProfile{
+	// One range reader per read avoids bidi stream setup for one large read
+	// at a time, and reading 8 MiB ahead hides the round trip between blocks.
+	"seq_read": {
+		readStrategy:  readStrategyRangeReader,
+		readAheadSize: 8 << 20,
+	},
+	// Random reads share a MultiRangeDownloader per file. Read-ahead would
+	// only fetch data that is never read, and reusing pooled goroutines keeps
+	// churn down at the high iodepths these jobs use.
+	"rand_read": {
+		readStrategy: readStrategyMRD,
+		pooled:       true,
+	},
+	// Writes are synchronous, so read settings stay at their defaults.
+	"write": {
+		readStrategy: readStrategyMRD,
+	},
+	// Mixed jobs pick range readers only at an iodepth of 1, and don't read
+	// ahead, since reads interleave with writes.
+	"mixed": {
+		readStrategy: readStrategyAuto,
+		pooled:       true,
+	},
+}
+
+// GoStorageApplyWorkloadProfile configures td for a common fio pattern, one of
+// "seq_read", "rand_read", "write" or "mixed", setting its read strategy as
+// with GoStorageSetReadStrategy, its read-ahead as with GoStorageSetReadAhead,
+// and its worker pool as with GoStorageSetWorkerPool. Pools have a worker for
+// each operation td's iodepth allows in flight, since workers block on their
+// operations, and profiles without one remove any pool. Operations already
+// queued finish on the old pool. The read settings apply to files opened
+// afterwards. Returns 0 on success and a negative error code on error.
+//
+//export GoStorageApplyWorkloadProfile
+func GoStorageApplyWorkloadProfile(td uintptr, profileCstr *C.char) int {
+	profile := C.GoString(profileCstr)
+	slog.Debug("go storage apply workload profile",
+		"td", td,
+		"profile", profile,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("apply workload profile: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	p, ok := workloadProfiles[profile]
+	if !ok {
+		slog.Error("unknown workload profile", "profile", profile)
+		return int(codeInvalidArgument)
+	}
+	strategy := p.readStrategy
+	t.readStrategy.Store(&strategy)
+	t.readAheadSize.Store(p.readAheadSize)
+	workers := 0
+	if p.pooled {
+		workers = cap(t.completions)
+	}
+	t.resizeWorkerPool(workers)
+	return 0
+}
@@ -0,0 +1,153 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+	"unsafe"
+
+	"cloud.google.com/go/storage"
+)
+
+// Read strategies accepted by GoStorageSetReadStrategy.
+const (
+	readStrategyMRD         = "mrd"
+	readStrategyRangeReader = "range_reader"
+	readStrategyAuto        = "auto"
+)
+
+// useRangeReader reports whether files opened on t without O_DIRECT should
+// read with a range reader per read rather than a MultiRangeDownloader.
+func (t *threadData) useRangeReader() bool {
+	strategy := t.readStrategy.Load()
+	if strategy == nil {
+		return false
+	}
+	switch *strategy {
+	case readStrategyRangeReader:
+		return true
+	case readStrategyAuto:
+		return cap(t.completions) <= 1
+	}
+	return false
+}
+
+// rangeReaderFile reads with a new range reader for each read, which can beat
+// a MultiRangeDownloader for one sequential read at a time.
+type rangeReaderFile struct {
+	objectInfo
+	t *threadData
+	// If non-nil, overrides t.timeout.
+	timeout *time.Duration
+}
+
+// openRangeReaderFile opens a rangeReaderFile for oh, fetching its attributes
+// to check its size.
+func openRangeReaderFile(t *threadData, oh *storage.ObjectHandle) (*rangeReaderFile, error) {
+	f := &rangeReaderFile{
+		objectInfo: newObjectInfo(t, oh),
+		t:          t,
+	}
+	attrs, err := f.fetchAttrs()
+	if err != nil {
+		return nil, err
+	}
+	if t.maxObjectSize > 0 && attrs.Size > t.maxObjectSize {
+		return nil, fmt.Errorf("object size %d exceeds max object size %d: %w", attrs.Size, t.maxObjectSize, errInvalidArgument)
+	}
+	return f, nil
+}
+
+// newRangeReaderFileHandle opens a rangeReaderFile for oh and returns a handle
+// to it, or 0 on error.
+func newRangeReaderFileHandle(t *threadData, oh *storage.ObjectHandle, filename string) uintptr {
+	f, err := openRangeReaderFile(t, oh)
+	if err != nil {
+		slog.Error("failed range reader open",
+			"filename", filename,
+			"err", err,
+		)
+		return 0
+	}
+	return newThreadHandle(t, f)
+}
+
+func (r *rangeReaderFile) Close() error {
+	return nil
+}
+
+func (r *rangeReaderFile) enqueue(p []byte, offset int64, tag unsafe.Pointer) int {
+	if err := r.t.checkRange(offset, int64(len(p))); err != nil {
+		slog.Error("enqueue: invalid range", "err", err)
+		return int(errorCodeOf(err))
+	}
+	timeout := r.t.opTimeout(r.timeout)
+	r.t.spawn(func() {
+		ctx := context.Background()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		r.t.complete(tag, r.read(ctx, p, offset))
+	})
+	return fioQQueued
+}
+
+// read reads len(p) bytes at offset into p.
+func (r *rangeReaderFile) read(ctx context.Context, p []byte, offset int64) error {
+	rr, err := r.oh.NewRangeReader(ctx, offset, int64(len(p)))
+	if err != nil {
+		return fmt.Errorf("opening range reader: %w", err)
+	}
+	_, err = io.ReadFull(rr, p)
+	if closeErr := rr.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("reading range [%d, %d): %w", offset, offset+int64(len(p)), err)
+	}
+	return nil
+}
+
+func (r *rangeReaderFile) setTimeout(timeout *time.Duration) {
+	r.timeout = timeout
+}
+
+// GoStorageSetReadStrategy chooses how files later opened on td without
+// O_DIRECT read: "mrd", the default, reads through one MultiRangeDownloader
+// per file, "range_reader" opens a range reader for each read, which can be
+// faster for single sequential reads, and "auto" uses range readers only at
+// an iodepth of 1. Returns 0 on success and a negative error code on error.
+//
+//export GoStorageSetReadStrategy
+func GoStorageSetReadStrategy(td uintptr, strategyCstr *C.char) int {
+	strategy := C.GoString(strategyCstr)
+	slog.Debug("go storage set read strategy",
+		"td", td,
+		"strategy", strategy,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set read strategy: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	switch strategy {
+	case readStrategyMRD, readStrategyRangeReader, readStrategyAuto:
+	default:
+		slog.Error("unsupported read strategy", "strategy", strategy)
+		return int(codeInvalidArgument)
+	}
+	t.readStrategy.Store(&strategy)
+	return 0
+}
@@ -0,0 +1,169 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+)
+
+// reloadableConfig holds the settings GoStorageReloadConfig applies. Unset
+// fields leave the current setting alone.
+type reloadableConfig struct {
+	MaxRetries         *int    `json:"maxRetries"`
+	OperationTimeoutMs *int64  `json:"operationTimeoutMs"`
+	LogLevel           *string `json:"logLevel"`
+	WorkerPoolSize     *int    `json:"workerPoolSize"`
+}
+
+// The JSON keys of reloadableConfig, the settings GoStorageReloadConfig
+// recognizes but can't change once a thread has its client, and those it
+// rejects because they are set per file rather than per thread.
+var (
+	reloadableConfigKeys = []string{
+		"maxRetries",
+		"operationTimeoutMs",
+		"logLevel",
+		"workerPoolSize",
+	}
+	immutableConfigKeys = []string{
+		"iodepth",
+		"transport",
+		"endpoint",
+		"connectionPoolSize",
+		"credentialsFile",
+		"projectId",
+	}
+	perFileConfigKeys = []string{
+		"bandwidthLimit",
+	}
+)
+
+// apply applies rc to t, returning an error describing every setting that
+// couldn't be applied.
+func (rc reloadableConfig) apply(t *threadData) error {
+	var errs []error
+	if rc.MaxRetries != nil {
+		if *rc.MaxRetries < 0 {
+			errs = append(errs, fmt.Errorf("maxRetries must not be negative: %w", errInvalidArgument))
+		} else {
+			t.maxAttempts.Store(int64(*rc.MaxRetries) + 1)
+		}
+	}
+	if rc.OperationTimeoutMs != nil {
+		if *rc.OperationTimeoutMs < 0 {
+			errs = append(errs, fmt.Errorf("operationTimeoutMs must not be negative: %w", errInvalidArgument))
+		} else {
+			t.timeout.Store(int64(time.Duration(*rc.OperationTimeoutMs) * time.Millisecond))
+		}
+	}
+	if rc.LogLevel != nil {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(*rc.LogLevel)); err != nil {
+			errs = append(errs, fmt.Errorf("logLevel: %w: %w", err, errInvalidArgument))
+		} else {
+			slog.SetLogLoggerLevel(level)
+		}
+	}
+	if rc.WorkerPoolSize != nil {
+		if n := *rc.WorkerPoolSize; n < 0 || n > maxWorkers {
+			errs = append(errs, fmt.Errorf("workerPoolSize must be between 0 and %d: %w", maxWorkers, errInvalidArgument))
+		} else {
+			t.resizeWorkerPool(n)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resizeWorkerPool replaces t's worker pool with one of n workers, or none if
+// n is 0. Operations already queued on the old pool still run. The new pool is
+// in place before the old one drains, so operations queued meanwhile run on
+// the new one.
+func (t *threadData) resizeWorkerPool(n int) {
+	var p *workerPool
+	if n > 0 {
+		p = newWorkerPool(n)
+	}
+	if old := t.pool.Swap(p); old != nil {
+		old.drain()
+	}
+}
+
+// GoStorageReloadConfig reads a JSON configuration file at configPath and
+// applies it to td while it runs, e.g. from a job wrapper that adjusts
+// settings mid-benchmark. The recognized settings are:
+//
+//   - maxRetries: how many times to retry an operation, for objects opened
+//     from now on.
+//   - operationTimeoutMs: the default read timeout, as with
+//     GoStorageSetOperationTimeout.
+//   - logLevel: the log level, e.g. "INFO", for the whole process.
+//   - workerPoolSize: the size of td's worker pool, as with
+//     GoStorageSetWorkerPool. Operations already queued finish on the old
+//     pool.
+//
+// None of these require rebuilding the client. Settings fixed when the client
+// was built, such as iodepth, transport, endpoint and credentials, and
+// unrecognized settings, are logged and skipped. bandwidthLimit is rejected,
+// since bandwidth is limited per file, with GoStorageSetFileBandwidthLimit,
+// rather than per thread; the other settings are still applied. Returns 0 if
+// every recognized setting was applied, and a negative error code otherwise,
+// including if the file can't be read or parsed.
+//
+//export GoStorageReloadConfig
+func GoStorageReloadConfig(td uintptr, configPathCstr *C.char) int {
+	configPath := C.GoString(configPathCstr)
+	slog.Info("go storage reload config",
+		"td", td,
+		"config_path", configPath,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("reload config: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	b, err := os.ReadFile(configPath)
+	if err != nil {
+		slog.Error("reload config: failed to read config", "err", err)
+		return int(codeInvalidArgument)
+	}
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal(b, &settings); err != nil {
+		slog.Error("reload config: failed to parse config", "err", err)
+		return int(codeInvalidArgument)
+	}
+	var rc reloadableConfig
+	if err := json.Unmarshal(b, &rc); err != nil {
+		slog.Error("reload config: failed to parse config", "err", err)
+		return int(codeInvalidArgument)
+	}
+
+	var errs []error
+	for key := range settings {
+		switch {
+		case slices.Contains(immutableConfigKeys, key):
+			slog.Warn("reload config: setting can't change after init, skipping", "key", key)
+		case slices.Contains(perFileConfigKeys, key):
+			errs = append(errs, fmt.Errorf("%s is set per file with GoStorageSetFileBandwidthLimit: %w", key, errInvalidArgument))
+		case !slices.Contains(reloadableConfigKeys, key):
+			slog.Warn("reload config: unrecognized setting, skipping", "key", key)
+		}
+	}
+	errs = append(errs, rc.apply(t))
+	if err := errors.Join(errs...); err != nil {
+		slog.Error("reload config: failed to apply settings", "err", err)
+		return int(errorCodeOf(err))
+	}
+	return 0
+}
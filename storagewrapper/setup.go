@@ -0,0 +1,212 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// The size of each write when creating test objects, how often creating or
+// deleting them logs progress, and how long deleting them may take in total.
+const (
+	setupChunkSize     = 1 << 20
+	setupProgressEvery = 100
+	cleanupTimeout     = 10 * time.Minute
+)
+
+// writeTestObject writes size bytes to oh, repeating chunk.
+func writeTestObject(ctx context.Context, oh *storage.ObjectHandle, size int64, chunk []byte) error {
+	w := oh.NewWriter(ctx)
+	for size > 0 {
+		n := int64(len(chunk))
+		if size < n {
+			n = size
+		}
+		if _, err := w.Write(chunk[:n]); err != nil {
+			_ = w.Close()
+			return fmt.Errorf("writing %v: %w", oh.ObjectName(), err)
+		}
+		size -= n
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("closing writer for %v: %w", oh.ObjectName(), err)
+	}
+	return nil
+}
+
+// GoStorageSetupTestObjects creates objectCount objects in bucket, named
+// prefix followed by 0 through objectCount-1, each holding objectSizeBytes
+// bytes, so that benchmarks can prepare their data without a separate tool.
+// The objects are written by workerCount goroutines in parallel, and replace
+// any existing objects of the same name. The data is zeros, or random bytes if
+// randomData is set. Returns the number of objects created, which is less than
+// objectCount if some failed, and a negative error code on error.
+//
+//export GoStorageSetupTestObjects
+func GoStorageSetupTestObjects(td uintptr, bucketCstr, prefixCstr *C.char, objectCount C.int, objectSizeBytes int64, workerCount C.int, randomData bool) int {
+	bucket := C.GoString(bucketCstr)
+	prefix := C.GoString(prefixCstr)
+	slog.Info("go storage setup test objects",
+		"td", td,
+		"bucket", bucket,
+		"prefix", prefix,
+		"object_count", objectCount,
+		"object_size_bytes", objectSizeBytes,
+		"worker_count", workerCount,
+		"random_data", randomData,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("setup test objects: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if bucket == "" || objectCount < 0 || objectSizeBytes < 0 || workerCount <= 0 {
+		slog.Error("setup test objects: invalid argument")
+		return int(codeInvalidArgument)
+	}
+
+	b := t.bucket(bucket)
+	names := make(chan string)
+	var created, done atomic.Int64
+	var wg sync.WaitGroup
+	for range int(workerCount) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			chunk := make([]byte, min(objectSizeBytes, setupChunkSize))
+			if randomData {
+				_, _ = rand.Read(chunk)
+			}
+			for name := range names {
+				if err := writeTestObject(t.ctx, b.Object(name), objectSizeBytes, chunk); err != nil {
+					slog.Error("setup test objects: failed to create object",
+						"bucket", bucket,
+						"object", name,
+						"err", err,
+					)
+				} else {
+					created.Add(1)
+				}
+				if n := done.Add(1); n%setupProgressEvery == 0 {
+					slog.Info("setup test objects: progress",
+						"done", n,
+						"created", created.Load(),
+						"object_count", objectCount,
+					)
+				}
+			}
+		}()
+	}
+	for i := range int(objectCount) {
+		names <- prefix + strconv.Itoa(i)
+	}
+	close(names)
+	wg.Wait()
+
+	slog.Info("setup test objects: finished",
+		"created", created.Load(),
+		"object_count", objectCount,
+	)
+	return int(created.Load())
+}
+
+// GoStorageCleanupObjects deletes every object in bucket whose name begins with
+// prefix, such as those made by GoStorageSetupTestObjects, using workerCount
+// goroutines in parallel. prefix may not be empty, so that a missing argument
+// can't empty the whole bucket. Cleanup gives up after 10 minutes. Returns the
+// number of objects deleted, which falls short if listing or some deletes
+// failed, and a negative error code on error.
+//
+//export GoStorageCleanupObjects
+func GoStorageCleanupObjects(td uintptr, bucketCstr, prefixCstr *C.char, workerCount C.int) int {
+	bucket := C.GoString(bucketCstr)
+	prefix := C.GoString(prefixCstr)
+	slog.Info("go storage cleanup objects",
+		"td", td,
+		"bucket", bucket,
+		"prefix", prefix,
+		"worker_count", workerCount,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("cleanup objects: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if bucket == "" || workerCount <= 0 {
+		slog.Error("cleanup objects: invalid argument")
+		return int(codeInvalidArgument)
+	}
+	if prefix == "" {
+		slog.Error("cleanup objects: empty prefix would delete the whole bucket", "bucket", bucket)
+		return int(codeInvalidArgument)
+	}
+
+	ctx, cancel := context.WithTimeout(t.ctx, cleanupTimeout)
+	defer cancel()
+	query := &storage.Query{Prefix: prefix}
+	if err := query.SetAttrSelection([]string{"Name"}); err != nil {
+		slog.Error("cleanup objects: failed to set attr selection", "err", err)
+		return int(errorCodeOf(err))
+	}
+	b := t.bucket(bucket)
+	names := make(chan string)
+	var deleted atomic.Int64
+	var wg sync.WaitGroup
+	for range int(workerCount) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range names {
+				if err := b.Object(name).Delete(ctx); err != nil {
+					slog.Error("cleanup objects: failed to delete object",
+						"bucket", bucket,
+						"object", name,
+						"err", err,
+					)
+					continue
+				}
+				if n := deleted.Add(1); n%setupProgressEvery == 0 {
+					slog.Info("cleanup objects: progress", "deleted", n)
+				}
+			}
+		}()
+	}
+	it := b.Objects(ctx, query)
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			slog.Error("cleanup objects: listing failed",
+				"bucket", bucket,
+				"prefix", prefix,
+				"err", err,
+			)
+			break
+		}
+		names <- attrs.Name
+	}
+	close(names)
+	wg.Wait()
+
+	slog.Info("cleanup objects: finished", "deleted", deleted.Load())
+	return int(deleted.Load())
+}
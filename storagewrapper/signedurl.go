@@ -0,0 +1,186 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// Host serving path-style GCS URLs.
+const gcsHost = "storage.googleapis.com"
+
+// signedURLFile reads an object through a signed URL over HTTP, since
+// MultiRangeDownloaders can't authenticate with one.
+type signedURLFile struct {
+	t       *threadData
+	url     string
+	expires time.Time
+	// If non-nil, overrides t.timeout.
+	timeout *time.Duration
+}
+
+// signedURLObject returns the bucket and object a signed URL refers to, for
+// both path-style and virtual-hosted-style URLs.
+func signedURLObject(u *url.URL) (bucket, object string, err error) {
+	path := strings.TrimPrefix(u.Path, "/")
+	if bucket, ok := strings.CutSuffix(u.Hostname(), "."+gcsHost); ok {
+		return bucket, path, nil
+	}
+	bucket, object, ok := strings.Cut(path, "/")
+	if !ok || bucket == "" || object == "" {
+		return "", "", fmt.Errorf("no bucket and object in signed URL path %q: %w", u.Path, errInvalidArgument)
+	}
+	return bucket, object, nil
+}
+
+// signedURLExpiry returns when a V4 or V2 signed URL expires.
+func signedURLExpiry(u *url.URL) (time.Time, error) {
+	q := u.Query()
+	if date := q.Get("X-Goog-Date"); date != "" {
+		start, err := time.Parse("20060102T150405Z", date)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parsing X-Goog-Date: %w", errInvalidArgument)
+		}
+		secs, err := strconv.ParseInt(q.Get("X-Goog-Expires"), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parsing X-Goog-Expires: %w", errInvalidArgument)
+		}
+		return start.Add(time.Duration(secs) * time.Second), nil
+	}
+	if expires := q.Get("Expires"); expires != "" {
+		secs, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parsing Expires: %w", errInvalidArgument)
+		}
+		return time.Unix(secs, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("no expiry in signed URL: %w", errInvalidArgument)
+}
+
+func (s *signedURLFile) Close() error {
+	return nil
+}
+
+func (s *signedURLFile) enqueue(p []byte, offset int64, tag unsafe.Pointer) int {
+	if time.Now().After(s.expires) {
+		slog.Error("enqueue: signed URL expired", "expires", s.expires)
+		return int(codeExpired)
+	}
+	if err := s.t.checkRange(offset, int64(len(p))); err != nil {
+		slog.Error("enqueue: invalid range", "err", err)
+		return int(errorCodeOf(err))
+	}
+	timeout := s.t.opTimeout(s.timeout)
+	s.t.spawn(func() {
+		ctx := context.Background()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		s.t.complete(tag, s.read(ctx, p, offset))
+	})
+	return fioQQueued
+}
+
+// read reads len(p) bytes at offset into p with a range request.
+func (s *signedURLFile) read(ctx context.Context, p []byte, offset int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+int64(len(p))-1))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting range: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// The server ignored the range and sent the whole object, which is
+		// only the requested range if it starts at 0 and is exactly as long.
+		if offset != 0 || (resp.ContentLength >= 0 && resp.ContentLength != int64(len(p))) {
+			return fmt.Errorf("requesting range [%d, %d): server sent the whole object, of %d bytes, instead", offset, offset+int64(len(p)), resp.ContentLength)
+		}
+	default:
+		return fmt.Errorf("requesting range: unexpected status %s", resp.Status)
+	}
+	if _, err := io.ReadFull(resp.Body, p); err != nil {
+		return fmt.Errorf("reading range [%d, %d): %w", offset, offset+int64(len(p)), err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		if n, _ := io.ReadFull(resp.Body, make([]byte, 1)); n > 0 {
+			return fmt.Errorf("requesting range [%d, %d): server sent the whole object, longer than the range, instead", offset, offset+int64(len(p)))
+		}
+	}
+	return nil
+}
+
+func (s *signedURLFile) setTimeout(timeout *time.Duration) {
+	s.timeout = timeout
+}
+
+// GoStorageOpenSigned opens a read file for the object a signed URL refers to,
+// for objects shared without IAM permissions. Reads are HTTP range requests
+// rather than through a MultiRangeDownloader, and once the URL expires they
+// fail with GO_STORAGE_ERR_EXPIRED instead of being sent.
+//
+//export GoStorageOpenSigned
+func GoStorageOpenSigned(td uintptr, signedURLCstr *C.char) uintptr {
+	signedURL := C.GoString(signedURLCstr)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("open signed: wrong type handle", "td", td)
+		return 0
+	}
+	u, err := url.Parse(signedURL)
+	if err != nil {
+		slog.Error("open signed: invalid URL", "err", err)
+		return 0
+	}
+	// Log without the query, which holds the signature.
+	bucket, object, err := signedURLObject(u)
+	if err != nil {
+		slog.Error("open signed: invalid URL", "err", err)
+		return 0
+	}
+	slog.Debug("go storage open signed",
+		"td", td,
+		"bucket", bucket,
+		"object", object,
+	)
+	expires, err := signedURLExpiry(u)
+	if err != nil {
+		slog.Error("open signed: invalid URL", "err", err)
+		return 0
+	}
+	if time.Now().After(expires) {
+		slog.Error("open signed: signed URL expired",
+			"bucket", bucket,
+			"object", object,
+			"expires", expires,
+		)
+		return 0
+	}
+	return newThreadHandle(t, &signedURLFile{
+		t:       t,
+		url:     signedURL,
+		expires: expires,
+	})
+}
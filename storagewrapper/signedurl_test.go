@@ -0,0 +1,142 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSignedURLObject(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		wantBucket string
+		wantObject string
+		wantErr    bool
+	}{
+		{
+			name:       "path style",
+			url:        "https://storage.googleapis.com/bucket/dir/object?X-Goog-Signature=abc",
+			wantBucket: "bucket",
+			wantObject: "dir/object",
+		},
+		{
+			name:       "virtual hosted style",
+			url:        "https://bucket.storage.googleapis.com/dir/object?X-Goog-Signature=abc",
+			wantBucket: "bucket",
+			wantObject: "dir/object",
+		},
+		{
+			name:       "virtual hosted style with port",
+			url:        "https://bucket.storage.googleapis.com:443/object",
+			wantBucket: "bucket",
+			wantObject: "object",
+		},
+		{
+			name:    "bucket only",
+			url:     "https://storage.googleapis.com/bucket",
+			wantErr: true,
+		},
+		{
+			name:    "empty object",
+			url:     "https://storage.googleapis.com/bucket/",
+			wantErr: true,
+		},
+		{
+			name:    "empty path",
+			url:     "https://storage.googleapis.com/",
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.Parse(tc.url)
+			if err != nil {
+				t.Fatalf("url.Parse(%q) error = %v", tc.url, err)
+			}
+			bucket, object, err := signedURLObject(u)
+			if tc.wantErr {
+				if !errors.Is(err, errInvalidArgument) {
+					t.Fatalf("signedURLObject() error = %v, want %v", err, errInvalidArgument)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("signedURLObject() error = %v", err)
+			}
+			if bucket != tc.wantBucket || object != tc.wantObject {
+				t.Errorf("signedURLObject() = %q, %q, want %q, %q", bucket, object, tc.wantBucket, tc.wantObject)
+			}
+		})
+	}
+}
+
+func TestSignedURLExpiry(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name:  "v4",
+			query: "X-Goog-Date=20260101T000000Z&X-Goog-Expires=3600",
+			want:  time.Date(2026, 1, 1, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "v2",
+			query: "Expires=1767225600",
+			want:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "v4 takes precedence",
+			query: "X-Goog-Date=20260101T000000Z&X-Goog-Expires=60&Expires=1",
+			want:  time.Date(2026, 1, 1, 0, 1, 0, 0, time.UTC),
+		},
+		{
+			name:    "v4 malformed date",
+			query:   "X-Goog-Date=2026-01-01&X-Goog-Expires=3600",
+			wantErr: true,
+		},
+		{
+			name:    "v4 missing expires",
+			query:   "X-Goog-Date=20260101T000000Z",
+			wantErr: true,
+		},
+		{
+			name:    "v2 malformed expires",
+			query:   "Expires=tomorrow",
+			wantErr: true,
+		},
+		{
+			name:    "no expiry",
+			query:   "X-Goog-Signature=abc",
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			u := &url.URL{Scheme: "https", Host: gcsHost, Path: "/bucket/object", RawQuery: tc.query}
+			got, err := signedURLExpiry(u)
+			if tc.wantErr {
+				if !errors.Is(err, errInvalidArgument) {
+					t.Fatalf("signedURLExpiry() error = %v, want %v", err, errInvalidArgument)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("signedURLExpiry() error = %v", err)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("signedURLExpiry() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
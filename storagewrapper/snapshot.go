@@ -0,0 +1,121 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"cloud.google.com/go/storage"
+)
+
+// The longest generation number, and so snapshot result, with its terminator.
+const maxGenerationLen = len("9223372036854775807") + 1
+
+// snapshotObject returns the snapshot of oh labelled label.
+func (t *threadData) snapshotObject(oh *storage.ObjectHandle, label string) *storage.ObjectHandle {
+	return t.bucket(oh.BucketName()).Object(oh.ObjectName() + ".snapshot." + label)
+}
+
+// GoStorageObjectSnapshot copies an object to a snapshot next to it, named
+// after the object with a ".snapshot.<snapshotLabel>" suffix, replacing any
+// snapshot with that label, so that it can be restored with
+// GoStorageObjectRestore after a destructive benchmark. The snapshot's
+// generation is written to buf as a decimal string. Returns the number of
+// bytes written, excluding the terminator, or a negative error code on error.
+//
+//export GoStorageObjectSnapshot
+func GoStorageObjectSnapshot(td uintptr, filenameCstr, snapshotLabelCstr, buf *C.char, bufLen C.int) int {
+	filename := C.GoString(filenameCstr)
+	label := C.GoString(snapshotLabelCstr)
+	slog.Debug("go storage object snapshot",
+		"td", td,
+		"filename", filename,
+		"snapshot_label", label,
+	)
+	if label == "" {
+		slog.Error("object snapshot: empty snapshot label")
+		return int(codeInvalidArgument)
+	}
+	// Check the buffer first, so that a snapshot isn't made without reporting
+	// it.
+	if buf == nil || int(bufLen) < maxGenerationLen {
+		slog.Error("object snapshot: buffer too small", "buf_len", bufLen)
+		return int(codeBufferTooSmall)
+	}
+	t, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("object snapshot: error getting *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	attrs, err := t.snapshotObject(oh, label).CopierFrom(oh).Run(context.Background())
+	if err != nil {
+		slog.Error("object snapshot: copy failed",
+			"filename", filename,
+			"err", err,
+		)
+		return int(errorCodeOf(err))
+	}
+	slog.Info("object snapshot created",
+		"filename", filename,
+		"snapshot", attrs.Name,
+		"generation", attrs.Generation,
+	)
+	return writeCString(buf, bufLen, strconv.FormatInt(attrs.Generation, 10))
+}
+
+// GoStorageObjectRestore copies the snapshot of an object labelled
+// snapshotLabel, made by GoStorageObjectSnapshot, back over the object, then
+// deletes the snapshot. Returns 0 on success and a negative error code on
+// error.
+//
+//export GoStorageObjectRestore
+func GoStorageObjectRestore(td uintptr, filenameCstr, snapshotLabelCstr *C.char) int {
+	filename := C.GoString(filenameCstr)
+	label := C.GoString(snapshotLabelCstr)
+	slog.Debug("go storage object restore",
+		"td", td,
+		"filename", filename,
+		"snapshot_label", label,
+	)
+	if label == "" {
+		slog.Error("object restore: empty snapshot label")
+		return int(codeInvalidArgument)
+	}
+	t, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("object restore: error getting *storage.ObjectHandle", "err", err)
+		return int(errorCodeOf(err))
+	}
+
+	ctx := context.Background()
+	snapshot := t.snapshotObject(oh, label)
+	if _, err := oh.CopierFrom(snapshot).Run(ctx); err != nil {
+		slog.Error("object restore: copy failed",
+			"filename", filename,
+			"err", err,
+		)
+		return int(errorCodeOf(err))
+	}
+	if err := snapshot.Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		slog.Error("object restore: failed to delete snapshot",
+			"snapshot", snapshot.ObjectName(),
+			"err", err,
+		)
+		return int(errorCodeOf(err))
+	}
+	slog.Info("object restored from snapshot",
+		"filename", filename,
+		"snapshot", snapshot.ObjectName(),
+	)
+	return 0
+}
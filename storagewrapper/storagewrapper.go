@@ -6,6 +6,18 @@
 
 package main
 
+/*
+#include <stdint.h>
+
+// A single read or write request for GoStorageQueueMulti.
+typedef struct {
+  uintptr_t handle;
+  void* iou;
+  int64_t offset;
+  void* buf;
+  int len;
+} GoStorageQueueRequest;
+*/
 import "C"
 
 import (
@@ -16,14 +28,21 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/url"
 	"runtime/cgo"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 	"unsafe"
 
 	"cloud.google.com/go/storage"
 	"cloud.google.com/go/storage/experimental"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -33,52 +52,218 @@ const (
 	fioQQueued = 1
 )
 
-func makeClient(endpoint string, connectionPoolSize int) (*storage.Client, error) {
+// clientConfig describes how to construct a client. It must stay comparable,
+// since it also keys the shared client cache.
+type clientConfig struct {
+	endpoint           string
+	connectionPoolSize int
+	lbPolicy           string
+	readBufferSize     int
+	writeBufferSize    int
+	jsonReads          bool
+	compressor         string
+	// If positive, bounds how long client construction may take.
+	dialTimeout time.Duration
+	// If non-zero, replaces gRPC's default connection backoff.
+	connectBackoff backoff.Config
+	// URL query encoded labels to attach to every request.
+	requestLabels string
+	// Whether to export client metrics to Cloud Monitoring, and the project
+	// billed for them.
+	clientMetrics bool
+	quotaProject  string
+	// TCP settings for dialing connections. A zero tcpKeepaliveIdle keeps Go's
+	// default keepalive.
+	tcpFastOpen      bool
+	tcpKeepaliveIdle time.Duration
+	// If non-empty, replaces the client's user agent.
+	userAgent string
+	// If positive, the largest gRPC messages the client sends and receives.
+	maxSendMsgSize int
+	maxRecvMsgSize int
+	// If positive, the largest header list the client accepts from GCS.
+	maxHeaderListSize uint32
+	// If positive, the most attempts made for each operation.
+	maxAttempts int
+	// If non-empty, a service account key file to authenticate with.
+	credentialsFile string
+	// If non-empty, the space separated OAuth2 scopes to request instead of
+	// the defaults.
+	scopes string
+	// If non-nil, a GoStorageDialFunc that dials connections instead of Go,
+	// overriding the TCP settings.
+	dialFn unsafe.Pointer
+	// If non-empty, the service whose health gRPC checks on each backend.
+	healthCheckService string
+	// If non-empty, PEM encoded CA certificates to verify GCS against, and a
+	// client certificate and key to present, instead of the defaults.
+	tlsCACertPEM     string
+	tlsClientCertPEM string
+	tlsClientKeyPEM  string
+	// If non-empty, an HTTP proxy to dial connections through, except to
+	// addresses in noProxyCIDR, overriding the TCP settings.
+	proxyURL    string
+	noProxyCIDR string
+	// If non-nil, a GoStorageUnaryInterceptor passed every unary call, with
+	// its user data.
+	unaryInterceptor     unsafe.Pointer
+	unaryInterceptorData unsafe.Pointer
+}
+
+func makeClient(cfg clientConfig) (*storage.Client, error) {
 	opts := []option.ClientOption{
-		// Client metrics are super verbose on startup, so turn them off.
-		storage.WithDisabledClientMetrics(),
 		experimental.WithGRPCBidiReads(),
 	}
-	if endpoint != "" {
-		opts = append(opts, option.WithEndpoint(endpoint))
+	if cfg.clientMetrics {
+		opts = append(opts, experimental.WithMetricInterval(metricInterval))
+	} else {
+		// Client metrics are super verbose on startup, so turn them off.
+		opts = append(opts, storage.WithDisabledClientMetrics())
+	}
+	if cfg.credentialsFile != "" {
+		opts = append(opts, option.WithAuthCredentialsFile(option.ServiceAccount, cfg.credentialsFile))
+	}
+	if cfg.scopes != "" {
+		opts = append(opts, option.WithScopes(strings.Fields(cfg.scopes)...))
+	}
+	if cfg.userAgent != "" {
+		opts = append(opts, option.WithUserAgent(cfg.userAgent))
+	}
+	if cfg.quotaProject != "" {
+		opts = append(opts, option.WithQuotaProject(cfg.quotaProject))
+	}
+	if cfg.jsonReads {
+		opts = append(opts, storage.WithJSONReads())
+	}
+	if cfg.endpoint != "" {
+		opts = append(opts, option.WithEndpoint(cfg.endpoint))
+	}
+	if cfg.connectionPoolSize > 1 {
+		opts = append(opts, option.WithGRPCConnectionPool(cfg.connectionPoolSize))
+	}
+	if cfg.lbPolicy != "" || cfg.healthCheckService != "" {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithDefaultServiceConfig(serviceConfig(cfg))))
+	}
+	if cfg.compressor != "" {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithDefaultCallOptions(grpc.UseCompressor(cfg.compressor))))
+	}
+	if cfg.connectBackoff != (backoff.Config{}) {
+		params := grpc.ConnectParams{Backoff: cfg.connectBackoff}
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithConnectParams(params)))
+	}
+	switch {
+	case cfg.dialFn != nil:
+		opts = append(opts, cDialerOption(cfg.dialFn))
+	case cfg.proxyURL != "":
+		opt, err := proxyDialerOption(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, opt)
+	case cfg.tcpFastOpen || cfg.tcpKeepaliveIdle != 0:
+		opts = append(opts, tcpDialerOption(cfg))
+	}
+	if cfg.tlsCACertPEM != "" || cfg.tlsClientCertPEM != "" {
+		opt, err := tlsTransportOption(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, opt)
+	}
+	if cfg.requestLabels != "" {
+		opts = append(opts, requestLabelOptions(cfg.requestLabels)...)
+	}
+	if cfg.unaryInterceptor != nil {
+		opts = append(opts, unaryInterceptorOption(cfg.unaryInterceptor, cfg.unaryInterceptorData))
+	}
+	if cfg.maxSendMsgSize > 0 {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(cfg.maxSendMsgSize))))
+	}
+	if cfg.maxRecvMsgSize > 0 {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(cfg.maxRecvMsgSize))))
 	}
-	if connectionPoolSize > 1 {
-		opts = append(opts, option.WithGRPCConnectionPool(connectionPoolSize))
+	if cfg.maxHeaderListSize > 0 {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithMaxHeaderListSize(cfg.maxHeaderListSize)))
 	}
-	c, err := storage.NewGRPCClient(context.Background(), opts...)
+	if cfg.readBufferSize > 0 {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithReadBufferSize(cfg.readBufferSize)))
+	}
+	if cfg.writeBufferSize > 0 {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithWriteBufferSize(cfg.writeBufferSize)))
+	}
+	ctx := context.Background()
+	if cfg.dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.dialTimeout)
+		defer cancel()
+	}
+	c, err := storage.NewGRPCClient(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("creating gRPC client: %w", err)
 	}
-	c.SetRetry(storage.WithErrorFunc(shouldRetry))
+	c.SetRetry(retryOptions(cfg)...)
 	return c, nil
 }
 
-type clientKey struct {
-	endpoint           string
-	connectionPoolSize int
+// serviceConfig returns the gRPC service config for a client built from cfg.
+func serviceConfig(cfg clientConfig) string {
+	var parts []string
+	if cfg.lbPolicy != "" {
+		parts = append(parts, fmt.Sprintf(`"loadBalancingPolicy":%q`, cfg.lbPolicy))
+	}
+	if cfg.healthCheckService != "" {
+		parts = append(parts, fmt.Sprintf(`"healthCheckConfig":{"serviceName":%q}`, cfg.healthCheckService))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+type sharedClientEntry struct {
+	client *storage.Client
+	refs   int
 }
 
 var (
 	sharedClientsMu sync.Mutex
-	sharedClients   = make(map[clientKey]*storage.Client)
+	sharedClients   = make(map[clientConfig]*sharedClientEntry)
 )
 
-func sharedClient(endpoint string, connectionPoolSize int) (*storage.Client, error) {
-	key := clientKey{endpoint, connectionPoolSize}
+// sharedClient returns the shared client for cfg, creating it if needed. Each
+// call must be paired with a call to releaseSharedClient.
+func sharedClient(cfg clientConfig) (*storage.Client, error) {
 	sharedClientsMu.Lock()
 	defer sharedClientsMu.Unlock()
-	if c, ok := sharedClients[key]; ok {
-		return c, nil
+	if e, ok := sharedClients[cfg]; ok {
+		e.refs++
+		return e.client, nil
 	}
 
-	c, err := makeClient(endpoint, connectionPoolSize)
+	c, err := makeClient(cfg)
 	if err != nil {
 		return nil, err
 	}
-	sharedClients[key] = c
+	sharedClients[cfg] = &sharedClientEntry{client: c, refs: 1}
 	return c, nil
 }
 
+// releaseSharedClient drops a reference to the shared client for cfg, closing
+// it once no threads use it.
+func releaseSharedClient(cfg clientConfig) {
+	sharedClientsMu.Lock()
+	defer sharedClientsMu.Unlock()
+	e, ok := sharedClients[cfg]
+	if !ok {
+		return
+	}
+	e.refs--
+	if e.refs > 0 {
+		return
+	}
+	delete(sharedClients, cfg)
+	if err := e.client.Close(); err != nil {
+		slog.Error("closing shared client (swallowing)", "err", err)
+	}
+}
+
 func init() {
 	// TODO: Consider doing this in the engine, via options.
 	slog.SetLogLoggerLevel(slog.LevelError)
@@ -93,37 +278,193 @@ func shouldRetry(err error) bool {
 	return result
 }
 
+// retryOptions returns the retry options for a client built from cfg.
+func retryOptions(cfg clientConfig) []storage.RetryOption {
+	opts := []storage.RetryOption{storage.WithErrorFunc(shouldRetry)}
+	if cfg.maxAttempts > 0 {
+		opts = append(opts, storage.WithMaxAttempts(cfg.maxAttempts))
+	}
+	return opts
+}
+
 type iouCompletion struct {
 	iou unsafe.Pointer
 	err error
+	// When the operation was queued, if known, and when it completed, in
+	// nanoseconds since the Unix epoch.
+	submitNs     int64
+	completionNs int64
 }
 
 type threadData struct {
 	completions       chan iouCompletion
 	reapedCompletions []iouCompletion
-	client            *storage.Client
+	// clientMu guards the client, which reconfigure replaces while other
+	// goroutines may be using it, the configuration it was built from, and
+	// whether it is shared with other threads.
+	clientMu     sync.RWMutex
+	client       *storage.Client
+	cfg          clientConfig
+	sharesClient bool
+	// The files, listings and upload sessions opened on the thread that
+	// haven't been closed, which keep using the client they were opened with.
+	openFiles atomic.Int64
+	// The operations in flight that use the client without holding a file
+	// open, such as queued deletes; see beginClientOp.
+	clientOps atomic.Int64
+	// ctx is cancelled by cleanup, stopping the thread's background work.
+	// Waits for completions don't select on it: cleanup runs on the thread
+	// that waits, so it can't be cancelled during a wait.
+	ctx      context.Context //nolint:containedctx // Scoped to the thread's lifetime.
+	cancelFn context.CancelFunc
+	// If positive, the largest object that may be opened or read.
+	maxObjectSize int64
+	// If positive, the default deadline for each read, as a time.Duration.
+	timeout atomic.Int64
+	// Whether opening a read file first checks the caller's access, and the
+	// bucket attributes cached for that check.
+	aclCheck         bool
+	bucketAttrsMu    sync.Mutex
+	bucketAttrsCache map[string]*storage.BucketAttrs
+	// If set, notified of each failed operation before it completes.
+	errorCallback atomic.Pointer[errorCallback]
+	// If non-nil, runs background operations instead of a goroutine each.
+	pool atomic.Pointer[workerPool]
+	// If non-nil, how files opened without O_DIRECT read; see
+	// GoStorageSetReadStrategy.
+	readStrategy atomic.Pointer[string]
+	// If positive, how far past the furthest read files read ahead.
+	readAheadSize atomic.Int64
+	// If positive, the most buckets with open read files, and the number of
+	// open files in each.
+	bucketsMu     sync.Mutex
+	maxBuckets    int
+	activeBuckets map[string]int
+	// Whether a background health check is running, and how many of its
+	// checks in a row have failed.
+	healthCheck         atomic.Bool
+	healthCheckFailures atomic.Int64
+	// How long files keep object attributes cached, as a time.Duration; see
+	// GoStorageSetCacheTTL.
+	attrsCacheTTL atomic.Int64
+	// The project that buckets are created in; see GoStorageSetProjectID.
+	projectID string
+	// If non-empty, the bucket of filenames that are bare object names; see
+	// GoStorageSetDefaultBucket.
+	defaultBucket string
+	// If positive, the alignment required of read offsets and buffers; see
+	// GoStorageSetAlignmentRequirement.
+	alignment atomic.Int64
+	// If positive, the most attempts made for each operation on handles made
+	// from now on, overriding the client's setting; see GoStorageReloadConfig.
+	maxAttempts atomic.Int64
+	// Read counters for each bucket read from.
+	bucketStatsMu sync.RWMutex
+	bucketStats   map[string]*bucketStatsEntry
+	// If set, logs each successful read; see GoStorageEnableAccessLog.
+	accessLog atomic.Pointer[accessLog]
+	// Options for each MultiRangeDownloader opened; see
+	// GoStorageSetMRDOptionJSON.
+	mrdOptions []storage.MRDOption
+	// When the completion last returned by GoStorageGetEvent was queued and
+	// completed; see GoStorageGetEventTimestamp.
+	lastSubmitNs     atomic.Int64
+	lastCompletionNs atomic.Int64
+	// The GCS host, and the network interface traffic to it is routed over,
+	// once looked up; see GoStorageGetNetworkInterface.
+	netIfaceHost string
+	netIfaceOnce sync.Once
+	netIface     string
+}
+
+// storageClient returns t's current client.
+func (t *threadData) storageClient() *storage.Client {
+	t.clientMu.RLock()
+	defer t.clientMu.RUnlock()
+	return t.client
+}
+
+// beginClientOp records that an operation using t's client, but not tied to
+// an open file, is in flight, so that reconfigure doesn't close the client
+// under it. Call it before queueing the operation, and the returned function
+// once the operation is done.
+func (t *threadData) beginClientOp() (done func()) {
+	t.clientMu.RLock()
+	defer t.clientMu.RUnlock()
+	t.clientOps.Add(1)
+	return func() {
+		t.clientOps.Add(-1)
+	}
+}
+
+// config returns the configuration t's current client was built from.
+func (t *threadData) config() clientConfig {
+	t.clientMu.RLock()
+	defer t.clientMu.RUnlock()
+	return t.cfg
+}
+
+// bucket returns a handle to the named bucket, with t's retry settings.
+func (t *threadData) bucket(name string) *storage.BucketHandle {
+	b := t.storageClient().Bucket(name)
+	if n := t.maxAttempts.Load(); n > 0 {
+		b = b.Retryer(storage.WithMaxAttempts(int(n)))
+	}
+	return b
+}
+
+// complete reports that the operation identified by iou finished with err.
+func (t *threadData) complete(iou unsafe.Pointer, err error) {
+	c := iouCompletion{iou: iou, err: err, completionNs: time.Now().UnixNano()}
+	if op, ok := inflightIOs.LoadAndDelete(iou); ok {
+		c.submitNs = op.(inflightIO).queued.UnixNano()
+		t.recordRead(op.(inflightIO), err)
+		if err == nil {
+			t.logRead(op.(inflightIO))
+		}
+	}
+	completeDedup(iou, err)
+	if err != nil {
+		t.notifyError(iou, err)
+	}
+	t.completions <- c
 }
 
 type mrdFile struct {
-	completions chan<- iouCompletion
-	mrd         *storage.MultiRangeDownloader
+	objectInfo
+	t   *threadData
+	mrd *storage.MultiRangeDownloader
+	// If non-nil, overrides t.timeout.
+	timeout *time.Duration
+	// If non-nil, serves reads that were fetched ahead.
+	ra *readAhead
 }
 
 type oDirectMrdFile struct {
-	completions chan<- iouCompletion
-	oh          *storage.ObjectHandle
+	objectInfo
+	t *threadData
+	// If non-nil, overrides t.timeout.
+	timeout *time.Duration
 }
 
 type writerFile struct {
+	// mu serializes use of w between fio and the interval flusher.
+	mu                   sync.Mutex
 	w                    *storage.Writer
 	flushAfterEveryWrite bool
+	// Closing stopFlusher stops the interval flusher, if one is running.
+	stopFlusher chan struct{}
+	// Whether anything has been written, after which w's chunk size is fixed,
+	// and the chunk size set by GoStorageSetUploadChunkSize, if any.
+	written   bool
+	chunkSize int
 }
 
 type goFile interface {
 	io.Closer
 	// Enqueues an operation appropriate for this file type. Implementations must
 	// return 0 for successfully completed operations, 1 for enqueued operations,
-	// and -1 for failed operations.
+	// and a negative error code for failed operations.
 	enqueue(p []byte, offset int64, tag unsafe.Pointer) int
 }
 
@@ -136,18 +477,42 @@ func handle[T any](v uintptr) (T, cgo.Handle, bool) {
 	return t, h, true
 }
 
+// writeCString copies s into the C buffer buf of length bufLen, followed by a
+// NUL terminator. Returns len(s), or codeBufferTooSmall if buf is too small.
+func writeCString(buf *C.char, bufLen C.int, s string) int {
+	if buf == nil || len(s) >= int(bufLen) {
+		return int(codeBufferTooSmall)
+	}
+	b := unsafe.Slice((*byte)(unsafe.Pointer(buf)), int(bufLen))
+	copy(b, s)
+	b[len(s)] = 0
+	return len(s)
+}
+
 func filenameObjectHandle(td uintptr, filename string) (*threadData, *storage.ObjectHandle, error) {
-	bucket, object, ok := strings.Cut(filename, "/")
+	t, _, ok := handle[*threadData](td)
 	if !ok {
-		return nil, nil, fmt.Errorf("could not extract bucket from filename %v", filename)
+		return nil, nil, fmt.Errorf("handle %d not of type *threadData: %w", td, errBadHandle)
 	}
 
-	t, _, ok := handle[*threadData](td)
+	bucket, object, ok := strings.Cut(filename, "/")
 	if !ok {
-		return nil, nil, fmt.Errorf("handle %d not of type *threadData", td)
+		if t.defaultBucket == "" {
+			return nil, nil, fmt.Errorf("could not extract bucket from filename %v: %w", filename, errInvalidArgument)
+		}
+		slog.Debug("using default bucket",
+			"filename", filename,
+			"bucket", t.defaultBucket,
+		)
+		bucket, object = t.defaultBucket, filename
+	}
+	// Object names may be URL encoded to survive fio's job file parsing.
+	object, err := url.PathUnescape(object)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding object name in filename %v: %w", filename, errInvalidArgument)
 	}
 
-	return t, t.client.Bucket(bucket).Object(object), nil
+	return t, t.bucket(bucket).Object(object), nil
 }
 
 //export GoStorageInit
@@ -160,23 +525,81 @@ func GoStorageInit(iodepth uint, endpoint_override *C.char, connection_pool_size
 		"share_client", share_client,
 	)
 
+	cfg := clientConfig{
+		endpoint:           endpoint,
+		connectionPoolSize: connection_pool_size,
+	}
+	return initThreadData(iodepth, cfg, share_client)
+}
+
+func initThreadData(iodepth uint, cfg clientConfig, shareClient bool) uintptr {
 	c, err := func() (*storage.Client, error) {
-		if share_client {
-			return sharedClient(endpoint, connection_pool_size)
+		if shareClient {
+			return sharedClient(cfg)
 		}
-		return makeClient(endpoint, connection_pool_size)
+		return makeClient(cfg)
 	}()
 	if err != nil {
 		slog.Error("failed client creation", "err", err)
 		return 0
 	}
+	return newThreadData(iodepth, cfg, c, shareClient)
+}
 
+// newThreadData returns a handle to a new thread using client c, built from
+// cfg.
+func newThreadData(iodepth uint, cfg clientConfig, c *storage.Client, shareClient bool) uintptr {
+	ctx, cancel := context.WithCancel(context.Background())
 	td := &threadData{
 		completions:       make(chan iouCompletion, iodepth),
 		reapedCompletions: make([]iouCompletion, 0, iodepth),
 		client:            c,
+		cfg:               cfg,
+		sharesClient:      shareClient,
+		ctx:               ctx,
+		cancelFn:          cancel,
+		netIfaceHost:      endpointHost(cfg.endpoint),
+	}
+	td.attrsCacheTTL.Store(int64(defaultAttrsCacheTTL))
+	// Log the network interface in use without delaying initialization.
+	go td.networkInterface()
+	return newHandle(td)
+}
+
+// releaseClient closes t's client, or drops t's reference to it if shared.
+// t.clientMu must be held, or t no longer in use.
+func (t *threadData) releaseClient() {
+	if t.sharesClient {
+		releaseSharedClient(t.cfg)
+		return
+	}
+	if err := t.client.Close(); err != nil {
+		slog.Error("closing client (swallowing)", "err", err)
+	}
+}
+
+// reconfigure replaces t's client with a new, unshared client built from cfg.
+// Open files and operations in flight would keep using the old client, which
+// is closed if t owned it, so reconfigure fails with errInvalidArgument if
+// there are any.
+func (t *threadData) reconfigure(cfg clientConfig) error {
+	t.clientMu.Lock()
+	defer t.clientMu.Unlock()
+	if n := t.openFiles.Load(); n > 0 {
+		return fmt.Errorf("cannot rebuild client with %d files open: %w", n, errInvalidArgument)
+	}
+	if n := t.clientOps.Load(); n > 0 {
+		return fmt.Errorf("cannot rebuild client with %d operations in flight: %w", n, errInvalidArgument)
 	}
-	return uintptr(cgo.NewHandle(td))
+	c, err := makeClient(cfg)
+	if err != nil {
+		return err
+	}
+	t.releaseClient()
+	t.client = c
+	t.cfg = cfg
+	t.sharesClient = false
+	return nil
 }
 
 //export GoStorageCleanup
@@ -185,12 +608,20 @@ func GoStorageCleanup(td uintptr) {
 	if td == 0 {
 		return
 	}
-	_, h, ok := handle[*threadData](td)
+	t, h, ok := handle[*threadData](td)
 	if !ok {
 		slog.Error("cleanup: wrong type handle", "td", td)
 		return
 	}
-	h.Delete()
+	t.cancelFn()
+	if p := t.pool.Swap(nil); p != nil {
+		// Queued operations still complete, if only with errors, rather than
+		// leaving their ious pending.
+		p.drain()
+	}
+	t.releaseClient()
+	t.closeAccessLog()
+	deleteHandle(h)
 }
 
 //export GoStorageAwaitCompletions
@@ -205,28 +636,259 @@ func GoStorageAwaitCompletions(td uintptr, cmin, cmax C.uint) int {
 	t, _, ok := handle[*threadData](td)
 	if !ok {
 		slog.Error("await completions: wrong type handle", "td", td)
-		return -1
+		return int(codeBadHandle)
+	}
+	return t.awaitCompletions(minCmps, maxCmps, false)
+}
+
+// GoStorageAwaitCompletionsLogged is like GoStorageAwaitCompletions, but also
+// logs the error of each failed completion as soon as it is reaped, rather
+// than leaving it to be noticed at GoStorageGetEvent.
+//
+//export GoStorageAwaitCompletionsLogged
+func GoStorageAwaitCompletionsLogged(td uintptr, cmin, cmax C.uint) int {
+	minCmps := int(cmin)
+	maxCmps := int(cmax)
+	slog.Debug("go storage await completions logged",
+		"td", td,
+		"min", minCmps,
+		"max", maxCmps,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("await completions logged: wrong type handle", "td", td)
+		return int(codeBadHandle)
 	}
+	return t.awaitCompletions(minCmps, maxCmps, true)
+}
 
+// awaitCompletions blocks until at least minCmps completions are reaped, then
+// reaps any that are ready up to maxCmps, logging failed ones if logErrors is
+// set. Returns the number of reaped completions.
+func (t *threadData) awaitCompletions(minCmps, maxCmps int, logErrors bool) int {
 	for len(t.reapedCompletions) < minCmps {
 		slog.Debug("remaining min completions", "count", minCmps-len(t.reapedCompletions))
-		t.reapedCompletions = append(t.reapedCompletions, <-t.completions)
+		t.reap(<-t.completions, logErrors)
 	}
 	slog.Debug("reaped completions", "count", len(t.reapedCompletions))
 
-	func() {
-		for len(t.reapedCompletions) < maxCmps {
-			slog.Debug("remaining max completions", "count", maxCmps-len(t.reapedCompletions))
-			select {
-			case v := <-t.completions:
+	t.reapReady(maxCmps, logErrors)
+	slog.Debug("reaped total completions", "count", len(t.reapedCompletions))
+	return len(t.reapedCompletions)
+}
+
+// reap adds v to reapedCompletions, first logging its error if it failed and
+// logErrors is set.
+func (t *threadData) reap(v iouCompletion, logErrors bool) {
+	if logErrors && v.err != nil {
+		slog.Error("completion failed",
+			"iou", uintptr(v.iou),
+			"err", v.err,
+		)
+	}
+	t.reapedCompletions = append(t.reapedCompletions, v)
+}
+
+// GoStorageReapAll blocks until at least one completion is available or maxMs
+// milliseconds elapse, then reaps every ready completion up to the iodepth.
+// Returns the number of reaped completions, or a negative error code on error.
+//
+//export GoStorageReapAll
+func GoStorageReapAll(td uintptr, maxMs int64) int {
+	slog.Debug("go storage reap all",
+		"td", td,
+		"max_ms", maxMs,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("reap all: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+
+	if len(t.reapedCompletions) == 0 {
+		select {
+		case v := <-t.completions:
+			t.reapedCompletions = append(t.reapedCompletions, v)
+		case <-time.After(time.Duration(maxMs) * time.Millisecond):
+			return 0
+		}
+	}
+	t.reapReady(cap(t.completions), false)
+	return len(t.reapedCompletions)
+}
+
+// GoStorageFlushCompletions reaps every completion that is ready, without
+// blocking, and regardless of how many are already reaped. Returns the number
+// of completions added, or a negative error code on error.
+//
+//export GoStorageFlushCompletions
+func GoStorageFlushCompletions(td uintptr) int {
+	slog.Debug("go storage flush completions", "td", td)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("flush completions: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+
+	added := 0
+	for {
+		select {
+		case v := <-t.completions:
+			t.reapedCompletions = append(t.reapedCompletions, v)
+			added++
+		default:
+			return added
+		}
+	}
+}
+
+// GoStorageAwaitGroup blocks until each of the count operations in ious has
+// completed, e.g. every read on a file before closing it, or until timeoutMs
+// milliseconds elapse. Completions of the group are consumed and won't be
+// returned by GoStorageGetEvent; others reaped meanwhile are kept for it. If
+// results is non-NULL, it must have room for count results, and the result of
+// each operation in ious is written to the same index: 0 if it succeeded, a
+// negative error code if it failed, and 1 if it hasn't completed by the
+// timeout, in which case GoStorageGetEvent returns it once it does. Failed
+// operations are also logged. Returns the number of the group's operations
+// that completed, including failed ones, which is less than count on timeout,
+// or a negative error code on error.
+//
+//export GoStorageAwaitGroup
+func GoStorageAwaitGroup(td uintptr, ious *unsafe.Pointer, results *C.int, count C.int, timeoutMs int64) int {
+	slog.Debug("go storage await group",
+		"td", td,
+		"count", count,
+		"timeout_ms", timeoutMs,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("await group: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if count < 0 || (ious == nil && count > 0) || timeoutMs < 0 {
+		slog.Error("await group: invalid argument")
+		return int(codeInvalidArgument)
+	}
+
+	var out []C.int
+	if results != nil {
+		out = unsafe.Slice(results, int(count))
+	}
+	// The index in ious of each operation still awaited.
+	awaited := make(map[unsafe.Pointer]int, int(count))
+	for i, iou := range unsafe.Slice(ious, int(count)) {
+		awaited[iou] = i
+		if out != nil {
+			out[i] = 1
+		}
+	}
+	found := 0
+	complete := func(v iouCompletion) bool {
+		i, ok := awaited[v.iou]
+		if !ok {
+			return false
+		}
+		delete(awaited, v.iou)
+		found++
+		res := C.int(0)
+		if v.err != nil {
+			slog.Error("await group: completion failed",
+				"iou", uintptr(v.iou),
+				"err", v.err,
+			)
+			res = C.int(errorCodeOf(v.err))
+		}
+		if out != nil {
+			out[i] = res
+		}
+		return true
+	}
+	kept := t.reapedCompletions[:0]
+	for _, v := range t.reapedCompletions {
+		if !complete(v) {
+			kept = append(kept, v)
+		}
+	}
+	t.reapedCompletions = kept
+
+	timer := time.NewTimer(time.Duration(timeoutMs) * time.Millisecond)
+	defer timer.Stop()
+	for len(awaited) > 0 {
+		select {
+		case v := <-t.completions:
+			if !complete(v) {
 				t.reapedCompletions = append(t.reapedCompletions, v)
-			default:
-				return
 			}
+		case <-timer.C:
+			slog.Warn("await group: timed out",
+				"found", found,
+				"remaining", len(awaited),
+			)
+			return found
 		}
-	}()
-	slog.Debug("reaped total completions", "count", len(t.reapedCompletions))
-	return len(t.reapedCompletions)
+	}
+	return found
+}
+
+// GoStorageGetQueueMetrics writes a snapshot of td's completion queue, for
+// spotting saturation while tuning: the completions waiting to be reaped to
+// *channelLen, the most that can wait, which is the iodepth, to *channelCap,
+// and the completions reaped but not yet returned by GoStorageGetEvent to
+// *reapedLen. NULL pointers are skipped. Returns 0 on success and a negative
+// error code on error.
+//
+//export GoStorageGetQueueMetrics
+func GoStorageGetQueueMetrics(td uintptr, channelLen, channelCap, reapedLen *C.int) int {
+	slog.Debug("go storage get queue metrics", "td", td)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("get queue metrics: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if channelLen != nil {
+		*channelLen = C.int(len(t.completions))
+	}
+	if channelCap != nil {
+		*channelCap = C.int(cap(t.completions))
+	}
+	if reapedLen != nil {
+		*reapedLen = C.int(len(t.reapedCompletions))
+	}
+	return 0
+}
+
+// GoStorageGetSaturationRatio returns how full td's completion queue is, from
+// 0.0 when empty to 1.0 when completing operations must wait to be reaped, or
+// a negative error code on error.
+//
+//export GoStorageGetSaturationRatio
+func GoStorageGetSaturationRatio(td uintptr) C.double {
+	slog.Debug("go storage get saturation ratio", "td", td)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("get saturation ratio: wrong type handle", "td", td)
+		return C.double(codeBadHandle)
+	}
+	if cap(t.completions) == 0 {
+		return 0
+	}
+	return C.double(float64(len(t.completions)) / float64(cap(t.completions)))
+}
+
+// reapReady moves completions into reapedCompletions, without blocking, until
+// there are maxCmps reaped completions or none are ready, logging failed ones
+// if logErrors is set.
+func (t *threadData) reapReady(maxCmps int, logErrors bool) {
+	for len(t.reapedCompletions) < maxCmps {
+		slog.Debug("remaining max completions", "count", maxCmps-len(t.reapedCompletions))
+		select {
+		case v := <-t.completions:
+			t.reap(v, logErrors)
+		default:
+			return
+		}
+	}
 }
 
 //export GoStorageGetEvent
@@ -243,6 +905,8 @@ func GoStorageGetEvent(td uintptr) (iou unsafe.Pointer, ok bool) {
 	}
 	v := t.reapedCompletions[len(t.reapedCompletions)-1]
 	t.reapedCompletions = t.reapedCompletions[:len(t.reapedCompletions)-1]
+	t.lastSubmitNs.Store(v.submitNs)
+	t.lastCompletionNs.Store(v.completionNs)
 	ok = true
 	if v.err != nil {
 		slog.Error("get event: reaped completion error", "err", v.err)
@@ -251,6 +915,40 @@ func GoStorageGetEvent(td uintptr) (iou unsafe.Pointer, ok bool) {
 	return v.iou, ok
 }
 
+// GoStorageGetEventTimestamp returns when the operation last returned by
+// GoStorageGetEvent on td completed, in nanoseconds since the Unix epoch, for
+// latency distributions measured at the point of completion rather than of
+// reaping. Call it right after GoStorageGetEvent. Returns 0 if no event has
+// been returned, and a negative error code on error.
+//
+//export GoStorageGetEventTimestamp
+func GoStorageGetEventTimestamp(td uintptr) C.int64_t {
+	slog.Debug("go storage get event timestamp", "td", td)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("get event timestamp: wrong type handle", "td", td)
+		return C.int64_t(codeBadHandle)
+	}
+	return C.int64_t(t.lastCompletionNs.Load())
+}
+
+// GoStorageGetEventSubmitTimestamp is like GoStorageGetEventTimestamp, but
+// returns when the operation was queued, so that the time spent waiting to be
+// reaped can be told apart from the transfer. Returns 0 if no event has been
+// returned or its queue time isn't known, as for operations that aren't reads,
+// and a negative error code on error.
+//
+//export GoStorageGetEventSubmitTimestamp
+func GoStorageGetEventSubmitTimestamp(td uintptr) C.int64_t {
+	slog.Debug("go storage get event submit timestamp", "td", td)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("get event submit timestamp: wrong type handle", "td", td)
+		return C.int64_t(codeBadHandle)
+	}
+	return C.int64_t(t.lastSubmitNs.Load())
+}
+
 //export GoStorageOpenReadonly
 func GoStorageOpenReadonly(td uintptr, oDirect bool, filenameCstr *C.char) uintptr {
 	filename := C.GoString(filenameCstr)
@@ -264,12 +962,63 @@ func GoStorageOpenReadonly(td uintptr, oDirect bool, filenameCstr *C.char) uintp
 		slog.Error("open: error getting *storage.ObjectHandle", "err", err)
 		return 0
 	}
+	return openReadonly(t, oDirect, oh, filename)
+}
+
+// openReadonly opens a read file for oh, returning a handle to it or 0 on
+// error. filename is only used for logging.
+func openReadonly(t *threadData, oDirect bool, oh *storage.ObjectHandle, filename string) uintptr {
+	release, err := t.acquireBucket(oh.BucketName())
+	if err != nil {
+		slog.Error("open: bucket limit reached",
+			"filename", filename,
+			"err", err,
+		)
+		return 0
+	}
+	v := openReadFile(t, oDirect, oh, filename)
+	if release != nil {
+		if v == 0 {
+			release()
+		} else {
+			f, _, _ := handle[infoFile](v)
+			f.info().releaseBucket = release
+		}
+	}
+	return v
+}
+
+func openReadFile(t *threadData, oDirect bool, oh *storage.ObjectHandle, filename string) uintptr {
+	if t.aclCheck {
+		if err := t.checkReadAccess(context.Background(), oh); err != nil {
+			slog.Error("open: read access not confirmed",
+				"filename", filename,
+				"err", err,
+			)
+			return 0
+		}
+	}
 
 	if oDirect {
-		return uintptr(cgo.NewHandle(&oDirectMrdFile{t.completions, oh}))
+		return newThreadHandle(t, &oDirectMrdFile{
+			objectInfo: newObjectInfo(t, oh),
+			t:          t,
+		})
+	}
+	if t.useRangeReader() {
+		return newRangeReaderFileHandle(t, oh, filename)
 	}
 
-	mrd, err := oh.NewMultiRangeDownloader(context.Background())
+	mrd, err := oh.NewMultiRangeDownloader(context.Background(), t.mrdOptions...)
+	if status.Code(err) == codes.Unimplemented {
+		// Endpoints without bidi reads can still serve a range reader per read,
+		// which behaves the same to callers.
+		slog.Warn("MRD unsupported by endpoint, falling back to range reads",
+			"filename", filename,
+			"err", err,
+		)
+		return newRangeReaderFileHandle(t, oh, filename)
+	}
 	if err != nil {
 		slog.Error("failed MRD open",
 			"filename", filename,
@@ -277,7 +1026,48 @@ func GoStorageOpenReadonly(td uintptr, oDirect bool, filenameCstr *C.char) uintp
 		)
 		return 0
 	}
-	return uintptr(cgo.NewHandle(&mrdFile{t.completions, mrd}))
+	if t.maxObjectSize > 0 && mrd.Attrs.Size > t.maxObjectSize {
+		slog.Error("object exceeds max object size",
+			"filename", filename,
+			"size", mrd.Attrs.Size,
+			"max", t.maxObjectSize,
+		)
+		if err := mrd.Close(); err != nil {
+			slog.Error("go storage close error (swallowing)", "err", err)
+		}
+		return 0
+	}
+	f := &mrdFile{
+		objectInfo: newObjectInfo(t, oh),
+		t:          t,
+		mrd:        mrd,
+	}
+	f.cacheAttrs(readerObjectAttrs(oh, mrd.Attrs))
+	if n := t.readAheadSize.Load(); n > 0 {
+		f.ra = newReadAhead(oh, n)
+	}
+	return newThreadHandle(t, f)
+}
+
+// GoStorageOpenRaw is like GoStorageOpenReadonly without O_DIRECT, but takes
+// the bucket and object names separately, and uses them as they are, without
+// splitting or URL decoding.
+//
+//export GoStorageOpenRaw
+func GoStorageOpenRaw(td uintptr, bucketCstr, objectCstr *C.char) uintptr {
+	bucket := C.GoString(bucketCstr)
+	object := C.GoString(objectCstr)
+	slog.Debug("go storage open raw",
+		"td", td,
+		"bucket", bucket,
+		"object", object,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("open raw: wrong type handle", "td", td)
+		return 0
+	}
+	return openReadonly(t, false, t.bucket(bucket).Object(object), bucket+"/"+object)
 }
 
 //export GoStorageOpenWriteonly
@@ -287,7 +1077,7 @@ func GoStorageOpenWriteonly(td uintptr, flushAfterEveryWrite bool, filenameCstr
 		"td", td,
 		"filename", filename,
 	)
-	_, oh, err := filenameObjectHandle(td, filename)
+	t, oh, err := filenameObjectHandle(td, filename)
 	if err != nil {
 		slog.Error("open: error getting *storage.ObjectHandle", "err", err)
 		return 0
@@ -295,7 +1085,10 @@ func GoStorageOpenWriteonly(td uintptr, flushAfterEveryWrite bool, filenameCstr
 
 	w := oh.Retryer(storage.WithPolicy(storage.RetryAlways)).NewWriter(context.Background())
 	w.Append = true
-	return uintptr(cgo.NewHandle(&writerFile{w, flushAfterEveryWrite}))
+	return newThreadHandle(t, &writerFile{
+		w:                    w,
+		flushAfterEveryWrite: flushAfterEveryWrite,
+	})
 }
 
 //export GoStorageClose
@@ -305,23 +1098,147 @@ func GoStorageClose(v uintptr) bool {
 	if !ok {
 		return false
 	}
-	h.Delete()
+	deleteHandle(h)
 	if err := f.Close(); err != nil {
 		slog.Error("go storage close error (swallowing)", "err", err)
 	}
+	if f, ok := f.(infoFile); ok && f.info().releaseBucket != nil {
+		f.info().releaseBucket()
+	}
 	return true
 }
 
 //export GoStorageQueue
 func GoStorageQueue(v uintptr, iou unsafe.Pointer, offset int64, b unsafe.Pointer, bl C.int) int {
 	slog.Debug("go storage queue", "handle", v)
+	return queue(v, iou, offset, b, bl)
+}
+
+// GoStorageQueueMulti queues count requests in a single call. If results is
+// non-NULL, it must have room for count entries, and each receives the
+// GoStorageQueue result for the matching request. Returns the number of
+// requests that were successfully queued or completed, or a negative error code
+// if td is invalid.
+//
+//export GoStorageQueueMulti
+func GoStorageQueueMulti(td uintptr, requests *C.GoStorageQueueRequest, count C.int, results *C.int) int {
+	slog.Debug("go storage queue multi",
+		"td", td,
+		"count", count,
+	)
+	if _, _, ok := handle[*threadData](td); !ok {
+		slog.Error("queue multi: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if count <= 0 {
+		return 0
+	}
+
+	reqs := unsafe.Slice(requests, int(count))
+	var res []C.int
+	if results != nil {
+		res = unsafe.Slice(results, int(count))
+	}
+	queued := 0
+	for i, r := range reqs {
+		result := queue(uintptr(r.handle), r.iou, int64(r.offset), r.buf, r.len)
+		if res != nil {
+			res[i] = C.int(result)
+		}
+		if result >= 0 {
+			queued++
+		}
+	}
+	return queued
+}
+
+// GoStorageQueueReadRange is like GoStorageQueue, but takes the range to read
+// from file v as [startOffset, endOffset), reading into the first
+// endOffset-startOffset bytes of b, which has room for bufLen. The range must
+// fit in b and, if the object's size is cached, in the object; otherwise a
+// range past the end fails when it is read. Returns the GoStorageQueue result,
+// or a negative error code if the range is invalid.
+//
+//export GoStorageQueueReadRange
+func GoStorageQueueReadRange(td, v uintptr, iou unsafe.Pointer, startOffset, endOffset int64, b unsafe.Pointer, bufLen C.int) int {
+	slog.Debug("go storage queue read range",
+		"td", td,
+		"handle", v,
+		"start_offset", startOffset,
+		"end_offset", endOffset,
+	)
+	if _, _, ok := handle[*threadData](td); !ok {
+		slog.Error("queue read range: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if startOffset < 0 || endOffset <= startOffset {
+		slog.Error("queue read range: empty or negative range",
+			"start_offset", startOffset,
+			"end_offset", endOffset,
+		)
+		return int(codeInvalidArgument)
+	}
+	length := endOffset - startOffset
+	if length > int64(bufLen) {
+		slog.Error("queue read range: range larger than buffer",
+			"start_offset", startOffset,
+			"end_offset", endOffset,
+			"length", length,
+			"buf_len", bufLen,
+		)
+		return int(codeInvalidArgument)
+	}
+	if f, _, ok := handle[infoFile](v); ok {
+		if attrs := f.info().cachedAttrs(); attrs != nil && endOffset > attrs.Size {
+			slog.Error("queue read range: range past end of object",
+				"start_offset", startOffset,
+				"end_offset", endOffset,
+				"object_size", attrs.Size,
+			)
+			return int(codeInvalidArgument)
+		}
+	}
+	return queue(v, iou, startOffset, b, C.int(length))
+}
+
+// checkQueue applies the checks every read of bl bytes at offset into b on
+// file f goes through before it is queued, for the queue call named by op:
+// the alignment required by f's thread, then f's bandwidth limit, waiting
+// until it allows the read. Returns 0 if the read may be queued, and a
+// negative error code otherwise.
+func checkQueue(op string, f any, offset int64, b unsafe.Pointer, bl C.int) int {
+	if f, ok := f.(threadFile); ok {
+		if err := f.thread().checkAlignment(offset, b); err != nil {
+			slog.Error(op+": misaligned read", "err", err)
+			return int(errorCodeOf(err))
+		}
+	}
+	if f, ok := f.(infoFile); ok {
+		if err := f.info().waitBandwidth(context.Background(), int(bl)); err != nil {
+			slog.Error(op+": bandwidth wait failed", "err", err)
+			return int(errorCodeOf(err))
+		}
+	}
+	return 0
+}
+
+func queue(v uintptr, iou unsafe.Pointer, offset int64, b unsafe.Pointer, bl C.int) int {
 	f, _, ok := handle[goFile](v)
 	if !ok {
 		slog.Error("queue: wrong type handle", "v", v)
-		return -1
+		return int(codeBadHandle)
+	}
+	if res := checkQueue("queue", f, offset, b, bl); res < 0 {
+		return res
 	}
 
-	return f.enqueue(C.GoBytes(b, bl), offset, iou)
+	enqueue := func() int {
+		return f.enqueue(C.GoBytes(b, bl), offset, iou)
+	}
+	if f, ok := f.(dedupFile); ok && iou != nil && dedupEnabled.Load() {
+		return queueDeduplicated(f, v, iou, offset, int64(bl), enqueue)
+	}
+	return trackInflight(v, iou, offset, int64(bl), enqueue)
 }
 
 func (m *mrdFile) Close() error {
@@ -332,9 +1249,18 @@ func (m *mrdFile) Close() error {
 }
 
 func (m *mrdFile) enqueue(p []byte, offset int64, tag unsafe.Pointer) int {
+	if err := m.t.checkRange(offset, int64(len(p))); err != nil {
+		slog.Error("enqueue: invalid range", "err", err)
+		return int(errorCodeOf(err))
+	}
+	if m.ra != nil && m.ra.serve(p, offset) {
+		m.t.recordRead(inflightIO{bucket: m.oh.BucketName(), n: int64(len(p))}, nil)
+		return fioQCompleted
+	}
+	complete := m.t.completeOnce(tag, m.t.opTimeout(m.timeout))
 	buf := bytes.NewBuffer(p)
 	m.mrd.Add(buf, offset, int64(len(p)), func(offset, length int64, err error) {
-		m.completions <- iouCompletion{tag, err}
+		complete(err)
 	})
 	return fioQQueued
 }
@@ -344,11 +1270,32 @@ func (o *oDirectMrdFile) Close() error {
 }
 
 func (o *oDirectMrdFile) enqueue(p []byte, offset int64, tag unsafe.Pointer) int {
-	go func() {
-		mrd, err := o.oh.NewMultiRangeDownloader(context.Background())
+	return o.enqueueContext(context.Background(), p, offset, tag, false)
+}
+
+// enqueueContext is enqueue, but opens the downloader with ctx, so that any
+// outgoing metadata in ctx is sent with the read, and if first is set, queues
+// the read ahead of others waiting on the thread's worker pool.
+func (o *oDirectMrdFile) enqueueContext(ctx context.Context, p []byte, offset int64, tag unsafe.Pointer, first bool) int {
+	if err := o.t.checkRange(offset, int64(len(p))); err != nil {
+		slog.Error("enqueue: invalid range", "err", err)
+		return int(errorCodeOf(err))
+	}
+	timeout := o.t.opTimeout(o.timeout)
+	spawn := o.t.spawn
+	if first {
+		spawn = o.t.spawnFirst
+	}
+	spawn(func() {
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		mrd, err := o.oh.NewMultiRangeDownloader(ctx, o.t.mrdOptions...)
 		if err != nil {
 			slog.Error("failed MRD open for O_DIRECT enqueue", "err", err)
-			o.completions <- iouCompletion{tag, err}
+			o.t.complete(tag, err)
 			return
 		}
 		buf := bytes.NewBuffer(p)
@@ -360,12 +1307,15 @@ func (o *oDirectMrdFile) enqueue(p []byte, offset int64, tag unsafe.Pointer) int
 		if err := mrd.Close(); err != nil {
 			addErr = fmt.Errorf("read error: %w; close error: %w", addErr, err)
 		}
-		o.completions <- iouCompletion{tag, addErr}
-	}()
+		o.t.complete(tag, addErr)
+	})
 	return fioQQueued
 }
 
 func (w *writerFile) Close() error {
+	w.setFlushInterval(0)
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	if err := w.w.Close(); err != nil {
 		return fmt.Errorf("closing writerFile: %w", err)
 	}
@@ -373,14 +1323,17 @@ func (w *writerFile) Close() error {
 }
 
 func (w *writerFile) enqueue(p []byte, offset int64, tag unsafe.Pointer) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = true
 	if _, err := w.w.Write(p); err != nil {
 		slog.Error("write error", "err", err)
-		return -1
+		return int(errorCodeOf(err))
 	}
 	if w.flushAfterEveryWrite {
 		if _, err := w.w.Flush(); err != nil {
 			slog.Error("flush error", "err", err)
-			return -1
+			return int(errorCodeOf(err))
 		}
 	}
 	return fioQCompleted
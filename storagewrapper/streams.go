@@ -0,0 +1,134 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"cloud.google.com/go/storage"
+)
+
+// Most streams GoStorageOpenMultiStream opens for one file.
+const maxStreams = 16
+
+// multiStreamMrdFile spreads reads of one object across several
+// MultiRangeDownloaders, for throughput beyond the per-stream limit.
+type multiStreamMrdFile struct {
+	objectInfo
+	t    *threadData
+	mrds []*storage.MultiRangeDownloader
+	next atomic.Uint64
+	// If non-nil, overrides t.timeout.
+	timeout *time.Duration
+}
+
+func (m *multiStreamMrdFile) Close() error {
+	var errs []error
+	for _, mrd := range m.mrds {
+		errs = append(errs, mrd.Close())
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("closing multiStreamMrdFile: %w", err)
+	}
+	return nil
+}
+
+func (m *multiStreamMrdFile) enqueue(p []byte, offset int64, tag unsafe.Pointer) int {
+	if err := m.t.checkRange(offset, int64(len(p))); err != nil {
+		slog.Error("enqueue: invalid range", "err", err)
+		return int(errorCodeOf(err))
+	}
+	complete := m.t.completeOnce(tag, m.t.opTimeout(m.timeout))
+	mrd := m.mrds[(m.next.Add(1)-1)%uint64(len(m.mrds))]
+	buf := bytes.NewBuffer(p)
+	mrd.Add(buf, offset, int64(len(p)), func(offset, length int64, err error) {
+		complete(err)
+	})
+	return fioQQueued
+}
+
+func (m *multiStreamMrdFile) setTimeout(timeout *time.Duration) {
+	m.timeout = timeout
+}
+
+// GoStorageOpenMultiStream is like GoStorageOpenReadonly without O_DIRECT, but
+// opens streamCount streams for the object, up to 16, and spreads reads across
+// them round robin.
+//
+//export GoStorageOpenMultiStream
+func GoStorageOpenMultiStream(td uintptr, filenameCstr *C.char, streamCount C.int) uintptr {
+	filename := C.GoString(filenameCstr)
+	slog.Debug("go storage open multi stream",
+		"td", td,
+		"filename", filename,
+		"stream_count", streamCount,
+	)
+	if streamCount < 1 || streamCount > maxStreams {
+		slog.Error("open multi stream: invalid stream count",
+			"stream_count", streamCount,
+			"max", maxStreams,
+		)
+		return 0
+	}
+	t, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("open: error getting *storage.ObjectHandle", "err", err)
+		return 0
+	}
+	if t.aclCheck {
+		if err := t.checkReadAccess(context.Background(), oh); err != nil {
+			slog.Error("open: read access not confirmed",
+				"filename", filename,
+				"err", err,
+			)
+			return 0
+		}
+	}
+
+	f := &multiStreamMrdFile{
+		objectInfo: newObjectInfo(t, oh),
+		t:          t,
+	}
+	for range int(streamCount) {
+		mrd, err := oh.NewMultiRangeDownloader(context.Background(), t.mrdOptions...)
+		if err != nil {
+			slog.Error("failed MRD open",
+				"filename", filename,
+				"err", err,
+			)
+			if err := f.Close(); err != nil {
+				slog.Error("go storage close error (swallowing)", "err", err)
+			}
+			return 0
+		}
+		f.mrds = append(f.mrds, mrd)
+	}
+
+	size := f.mrds[0].Attrs.Size
+	if t.maxObjectSize > 0 && size > t.maxObjectSize {
+		slog.Error("object exceeds max object size",
+			"filename", filename,
+			"size", size,
+			"max", t.maxObjectSize,
+		)
+		if err := f.Close(); err != nil {
+			slog.Error("go storage close error (swallowing)", "err", err)
+		}
+		return 0
+	}
+	f.cacheAttrs(readerObjectAttrs(oh, f.mrds[0].Attrs))
+	return newThreadHandle(t, f)
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"time"
+
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+// tcpDialerOption returns an option dialing gRPC connections with cfg's TCP
+// settings.
+func tcpDialerOption(cfg clientConfig) option.ClientOption {
+	d := &net.Dialer{KeepAlive: cfg.tcpKeepaliveIdle}
+	if cfg.tcpFastOpen {
+		d.Control = tcpFastOpenControl
+	}
+	return option.WithGRPCDialOption(grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		return d.DialContext(ctx, "tcp", addr)
+	}))
+}
+
+// GoStorageInitWithTCPOptions is like GoStorageInit, but dials connections
+// with TCP Fast Open if enableFastOpen is set, saving a round trip on
+// connection setup. A positive keepaliveIdleSeconds sends TCP keepalives after
+// that long idle, and a negative one disables them. TCP Fast Open is only
+// supported on Linux; elsewhere a warning is logged and connections use
+// standard TCP.
+//
+//export GoStorageInitWithTCPOptions
+func GoStorageInitWithTCPOptions(iodepth uint, enableFastOpen bool, keepaliveIdleSeconds C.int) uintptr {
+	slog.Info("go storage init with tcp options",
+		"iodepth", iodepth,
+		"enable_fast_open", enableFastOpen,
+		"keepalive_idle_seconds", keepaliveIdleSeconds,
+	)
+	if enableFastOpen && !tcpFastOpenSupported {
+		slog.Warn("tcp fast open is not supported on this platform; using standard tcp")
+		enableFastOpen = false
+	}
+	cfg := clientConfig{
+		tcpFastOpen:      enableFastOpen,
+		tcpKeepaliveIdle: time.Duration(keepaliveIdleSeconds) * time.Second,
+	}
+	return initThreadData(iodepth, cfg, false)
+}
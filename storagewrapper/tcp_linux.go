@@ -0,0 +1,31 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import (
+	"fmt"
+	"syscall"
+)
+
+const tcpFastOpenSupported = true
+
+// TCP_FASTOPEN_CONNECT from linux/tcp.h, which the syscall package lacks.
+const tcpFastOpenConnect = 30
+
+// tcpFastOpenControl enables TCP Fast Open on a socket before it connects.
+func tcpFastOpenControl(network, address string, c syscall.RawConn) error {
+	var sockErr error
+	if err := c.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, tcpFastOpenConnect, 1)
+	}); err != nil {
+		return fmt.Errorf("controlling socket: %w", err)
+	}
+	if sockErr != nil {
+		return fmt.Errorf("setting TCP_FASTOPEN_CONNECT: %w", sockErr)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+//go:build !linux
+
+package main
+
+import "syscall"
+
+const tcpFastOpenSupported = false
+
+// tcpFastOpenControl is never used where TCP Fast Open is unsupported.
+func tcpFastOpenControl(network, address string, c syscall.RawConn) error {
+	return nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// timeoutFile is implemented by files whose reads can have their own timeout.
+type timeoutFile interface {
+	setTimeout(timeout *time.Duration)
+}
+
+func (m *mrdFile) setTimeout(timeout *time.Duration) {
+	m.timeout = timeout
+}
+
+func (o *oDirectMrdFile) setTimeout(timeout *time.Duration) {
+	o.timeout = timeout
+}
+
+// opTimeout returns the timeout for a read on a file with the given override.
+func (t *threadData) opTimeout(override *time.Duration) time.Duration {
+	if override != nil {
+		return *override
+	}
+	return time.Duration(t.timeout.Load())
+}
+
+// completeOnce returns a function that completes iou with the error it is
+// first called with. If timeout is positive and elapses first, iou is
+// completed with an error wrapping context.DeadlineExceeded instead, and later
+// calls are ignored.
+func (t *threadData) completeOnce(iou unsafe.Pointer, timeout time.Duration) func(error) {
+	var (
+		once  sync.Once
+		timer atomic.Pointer[time.Timer]
+	)
+	complete := func(err error) {
+		once.Do(func() {
+			if tm := timer.Load(); tm != nil {
+				tm.Stop()
+			}
+			t.complete(iou, err)
+		})
+	}
+	if timeout > 0 {
+		timer.Store(time.AfterFunc(timeout, func() {
+			complete(fmt.Errorf("read timed out after %v: %w", timeout, context.DeadlineExceeded))
+		}))
+	}
+	return complete
+}
+
+// GoStorageSetOperationTimeout sets the default deadline for each read queued
+// on td. A timeoutMs of 0 means reads have no deadline. Returns 0 on success
+// and a negative error code on error.
+//
+//export GoStorageSetOperationTimeout
+func GoStorageSetOperationTimeout(td uintptr, timeoutMs int64) int {
+	slog.Debug("go storage set operation timeout",
+		"td", td,
+		"timeout_ms", timeoutMs,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("set operation timeout: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	if timeoutMs < 0 {
+		slog.Error("set operation timeout: negative timeout", "timeout_ms", timeoutMs)
+		return int(codeInvalidArgument)
+	}
+	t.timeout.Store(int64(time.Duration(timeoutMs) * time.Millisecond))
+	return 0
+}
+
+// GoStorageSetFileTimeout overrides the operation timeout for reads on file
+// v, e.g. to give large cold objects longer. A timeoutMs of 0 clears the
+// override, reverting to the thread's timeout. Returns 0 on success and a
+// negative error code on error.
+//
+//export GoStorageSetFileTimeout
+func GoStorageSetFileTimeout(v uintptr, timeoutMs int64) int {
+	slog.Debug("go storage set file timeout",
+		"handle", v,
+		"timeout_ms", timeoutMs,
+	)
+	f, _, ok := handle[timeoutFile](v)
+	if !ok {
+		slog.Error("set file timeout: not a read handle", "v", v)
+		return int(codeBadHandle)
+	}
+	if timeoutMs < 0 {
+		slog.Error("set file timeout: negative timeout", "timeout_ms", timeoutMs)
+		return int(codeInvalidArgument)
+	}
+	if timeoutMs == 0 {
+		f.setTimeout(nil)
+		return 0
+	}
+	timeout := time.Duration(timeoutMs) * time.Millisecond
+	f.setTimeout(&timeout)
+	return 0
+}
@@ -0,0 +1,69 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// tlsTransportOption returns an option securing gRPC connections with cfg's
+// CA and client certificates.
+func tlsTransportOption(cfg clientConfig) (option.ClientOption, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.tlsCACertPEM != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(cfg.tlsCACertPEM)) {
+			return nil, fmt.Errorf("no CA certificates found in PEM: %w", errInvalidArgument)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if cfg.tlsClientCertPEM != "" {
+		cert, err := tls.X509KeyPair([]byte(cfg.tlsClientCertPEM), []byte(cfg.tlsClientKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("parsing client certificate: %w: %w", err, errInvalidArgument)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	creds := credentials.NewTLS(tlsCfg)
+	return option.WithGRPCDialOption(grpc.WithTransportCredentials(creds)), nil
+}
+
+// GoStorageInitWithTLS is like GoStorageInit, but verifies GCS's certificate
+// against the CA certificates in caCertPEM, for deployments behind an internal
+// CA that the system doesn't trust, and presents the certificate in
+// clientCertPEM, with the key in clientKeyPEM, for mutual TLS. Each is a PEM
+// string and may be NULL: a NULL caCertPEM keeps the system roots, and a
+// client certificate is presented only if both clientCertPEM and clientKeyPEM
+// are set. Returns 0 on error.
+//
+//export GoStorageInitWithTLS
+func GoStorageInitWithTLS(iodepth uint, caCertPEM, clientCertPEM, clientKeyPEM *C.char) uintptr {
+	slog.Info("go storage init with tls",
+		"iodepth", iodepth,
+		"custom_ca", caCertPEM != nil,
+		"client_cert", clientCertPEM != nil && clientKeyPEM != nil,
+	)
+	cfg := clientConfig{tlsCACertPEM: C.GoString(caCertPEM)}
+	if clientCertPEM != nil && clientKeyPEM != nil {
+		cfg.tlsClientCertPEM = C.GoString(clientCertPEM)
+		cfg.tlsClientKeyPEM = C.GoString(clientKeyPEM)
+	}
+	if cfg.tlsCACertPEM == "" && cfg.tlsClientCertPEM == "" {
+		slog.Error("init with tls: no certificates")
+		return 0
+	}
+	return initThreadData(iodepth, cfg, false)
+}
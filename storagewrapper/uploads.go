@@ -0,0 +1,278 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"unsafe"
+
+	"cloud.google.com/go/storage"
+)
+
+// The most source objects GCS composes in one request.
+const maxComposeSources = 32
+
+// uploadSession uploads an object as parts written in parallel to temporary
+// objects, then composed into the final object.
+type uploadSession struct {
+	bucket     *storage.BucketHandle
+	oh         *storage.ObjectHandle
+	partSize   int64
+	totalParts int
+
+	// Counts part uploads that haven't finished.
+	pending sync.WaitGroup
+
+	mu         sync.Mutex
+	uploaded   []bool
+	finalizing bool
+	// The first part upload error, if any.
+	err error
+}
+
+// partObject returns the temporary object holding part n, or, for composing
+// more parts than fit in one request, intermediate object n at level.
+func (s *uploadSession) partObject(level, n int) *storage.ObjectHandle {
+	name := fmt.Sprintf("%s.upload-part-%d-%d", s.oh.ObjectName(), level, n)
+	return s.bucket.Object(name)
+}
+
+// uploadPart writes p as part n.
+func (s *uploadSession) uploadPart(ctx context.Context, n int, p []byte) error {
+	w := s.partObject(0, n).NewWriter(ctx)
+	if _, err := w.Write(p); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("writing part %d: %w", n, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("closing part %d: %w", n, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.uploaded[n] = true
+	return nil
+}
+
+// compose composes the parts into the final object, in part order, through
+// as many levels of intermediate objects as the compose limit requires.
+func (s *uploadSession) compose(ctx context.Context) error {
+	srcs := make([]*storage.ObjectHandle, s.totalParts)
+	for n := range srcs {
+		srcs[n] = s.partObject(0, n)
+	}
+	for level := 1; len(srcs) > maxComposeSources; level++ {
+		var next []*storage.ObjectHandle
+		for i := 0; i < len(srcs); i += maxComposeSources {
+			dst := s.partObject(level, len(next))
+			if _, err := dst.ComposerFrom(srcs[i:min(i+maxComposeSources, len(srcs))]...).Run(ctx); err != nil {
+				return fmt.Errorf("composing intermediate object %d at level %d: %w", len(next), level, err)
+			}
+			next = append(next, dst)
+		}
+		s.deleteObjects(ctx, srcs)
+		srcs = next
+	}
+	if _, err := s.oh.ComposerFrom(srcs...).Run(ctx); err != nil {
+		return fmt.Errorf("composing %v: %w", s.oh.ObjectName(), err)
+	}
+	s.deleteObjects(ctx, srcs)
+	return nil
+}
+
+// deleteObjects deletes temporary objects, logging failures, which leave the
+// object behind without affecting the upload.
+func (s *uploadSession) deleteObjects(ctx context.Context, objs []*storage.ObjectHandle) {
+	for _, o := range objs {
+		if err := o.Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+			slog.Error("upload session: failed to delete temporary object (swallowing)",
+				"object", o.ObjectName(),
+				"err", err,
+			)
+		}
+	}
+}
+
+// GoStorageCreateUploadSession starts uploading a large object as totalParts
+// parts of partSizeBytes each, except the last, which may be shorter. Parts
+// are queued with GoStorageQueuePart, in any order and in parallel, and the
+// object is assembled by GoStorageFinalizeUpload. Each part is stored as a
+// temporary object next to the destination until then. Returns a session
+// handle, or 0 on error.
+//
+//export GoStorageCreateUploadSession
+func GoStorageCreateUploadSession(td uintptr, filenameCstr *C.char, partSizeBytes int64, totalParts C.int) uintptr {
+	filename := C.GoString(filenameCstr)
+	slog.Debug("go storage create upload session",
+		"td", td,
+		"filename", filename,
+		"part_size_bytes", partSizeBytes,
+		"total_parts", totalParts,
+	)
+	if partSizeBytes <= 0 || totalParts <= 0 {
+		slog.Error("create upload session: invalid part size or count",
+			"part_size_bytes", partSizeBytes,
+			"total_parts", totalParts,
+		)
+		return 0
+	}
+	t, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("create upload session: error getting *storage.ObjectHandle", "err", err)
+		return 0
+	}
+	return newThreadHandle(t, &uploadSession{
+		bucket:     t.bucket(oh.BucketName()),
+		oh:         oh,
+		partSize:   partSizeBytes,
+		totalParts: int(totalParts),
+		uploaded:   make([]bool, totalParts),
+	})
+}
+
+// GoStorageQueuePart uploads bl bytes from b as part partNum, counting from 0,
+// of upload session session. Every part but the last must be exactly the
+// session's part size. iou completes on td once the part is stored. Returns 1
+// if queued, and a negative error code on error.
+//
+//export GoStorageQueuePart
+func GoStorageQueuePart(td, session uintptr, iou unsafe.Pointer, partNum C.int, b unsafe.Pointer, bl C.int) int {
+	slog.Debug("go storage queue part",
+		"td", td,
+		"session", session,
+		"part_num", partNum,
+		"len", bl,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("queue part: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	s, _, ok := handle[*uploadSession](session)
+	if !ok {
+		slog.Error("queue part: wrong type handle", "session", session)
+		return int(codeBadHandle)
+	}
+	n := int(partNum)
+	if n < 0 || n >= s.totalParts {
+		slog.Error("queue part: part number out of range",
+			"part_num", partNum,
+			"total_parts", s.totalParts,
+		)
+		return int(codeInvalidArgument)
+	}
+	if last := n == s.totalParts-1; int64(bl) > s.partSize || (!last && int64(bl) != s.partSize) || bl <= 0 {
+		slog.Error("queue part: wrong part size",
+			"part_num", partNum,
+			"len", bl,
+			"part_size", s.partSize,
+		)
+		return int(codeInvalidArgument)
+	}
+	s.mu.Lock()
+	if s.finalizing {
+		s.mu.Unlock()
+		slog.Error("queue part: session already finalizing", "session", session)
+		return int(codeInvalidArgument)
+	}
+	s.pending.Add(1)
+	s.mu.Unlock()
+
+	p := C.GoBytes(b, bl)
+	done := t.beginClientOp()
+	t.spawn(func() {
+		defer done()
+		defer s.pending.Done()
+		err := s.uploadPart(context.Background(), n, p)
+		if err != nil {
+			slog.Error("upload part failed",
+				"object", s.oh.ObjectName(),
+				"part_num", n,
+				"err", err,
+			)
+			s.mu.Lock()
+			if s.err == nil {
+				s.err = err
+			}
+			s.mu.Unlock()
+		}
+		t.complete(iou, err)
+	})
+	return fioQQueued
+}
+
+// GoStorageFinalizeUpload waits for every part queued on upload session
+// session, then composes them, in part order, into the destination object and
+// deletes the temporary part objects. Parts may have been uploaded in any
+// order. iou completes on td once the object is assembled, or with an error if
+// any part is missing or failed. The session handle is released immediately,
+// and parts may no longer be queued on it. Returns 1 if queued, and a negative
+// error code on error.
+//
+//export GoStorageFinalizeUpload
+func GoStorageFinalizeUpload(td, session uintptr, iou unsafe.Pointer) int {
+	slog.Debug("go storage finalize upload",
+		"td", td,
+		"session", session,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("finalize upload: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	s, h, ok := handle[*uploadSession](session)
+	if !ok {
+		slog.Error("finalize upload: wrong type handle", "session", session)
+		return int(codeBadHandle)
+	}
+	s.mu.Lock()
+	s.finalizing = true
+	s.mu.Unlock()
+	// Composing outlives the session handle.
+	done := t.beginClientOp()
+	deleteHandle(h)
+
+	go func() {
+		defer done()
+		s.pending.Wait()
+		ctx := context.Background()
+		err := func() error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.err != nil {
+				return fmt.Errorf("part upload failed: %w", s.err)
+			}
+			for n, ok := range s.uploaded {
+				if !ok {
+					return fmt.Errorf("part %d of %d was never uploaded: %w", n, s.totalParts, errInvalidArgument)
+				}
+			}
+			return nil
+		}()
+		if err == nil {
+			err = s.compose(ctx)
+		}
+		if err != nil {
+			slog.Error("finalize upload failed",
+				"object", s.oh.ObjectName(),
+				"err", err,
+			)
+			parts := make([]*storage.ObjectHandle, s.totalParts)
+			for n := range parts {
+				parts[n] = s.partObject(0, n)
+			}
+			s.deleteObjects(ctx, parts)
+		}
+		t.complete(iou, err)
+	}()
+	return fioQQueued
+}
@@ -0,0 +1,345 @@
+// Copyright 2026 Google LLC
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file or at
+// https://opensource.org/licenses/MIT.
+
+package main
+
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"unsafe"
+
+	"cloud.google.com/go/storage"
+)
+
+// setFlushInterval starts flushing w every interval in the background,
+// replacing any running flusher. A non-positive interval only stops the
+// running flusher.
+func (w *writerFile) setFlushInterval(interval time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopFlusher != nil {
+		close(w.stopFlusher)
+		w.stopFlusher = nil
+	}
+	if interval <= 0 {
+		return
+	}
+	stop := make(chan struct{})
+	w.stopFlusher = stop
+	go w.flushEvery(interval, stop)
+}
+
+func (w *writerFile) flushEvery(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		w.mu.Lock()
+		select {
+		case <-stop:
+			// Stopped while waiting for the lock; w may already be closed.
+			w.mu.Unlock()
+			return
+		default:
+		}
+		offset, err := w.w.Flush()
+		w.mu.Unlock()
+		if err != nil {
+			slog.Error("interval flush error", "err", err)
+			continue
+		}
+		slog.Debug("interval flush", "offset", offset)
+	}
+}
+
+// GoStorageSetFlushInterval makes write file v flush every intervalMs
+// milliseconds, bounding how much data is lost if the writer fails. Flushed
+// data is durable in the appendable object, and a failed write can be resumed
+// from it with GoStorageOpenWriteCheckpoint. An intervalMs of 0 disables
+// interval flushing. Returns 0 on success, and a negative error code if v is
+// not a write file or can't be flushed.
+//
+//export GoStorageSetFlushInterval
+func GoStorageSetFlushInterval(v uintptr, intervalMs int64) int {
+	slog.Debug("go storage set flush interval",
+		"handle", v,
+		"interval_ms", intervalMs,
+	)
+	w, _, ok := handle[*writerFile](v)
+	if !ok {
+		slog.Error("set flush interval: not a write handle", "v", v)
+		return int(codeBadHandle)
+	}
+	if !w.w.Append {
+		slog.Error("set flush interval: object isn't appendable", "v", v)
+		return int(codeInvalidArgument)
+	}
+	w.setFlushInterval(time.Duration(intervalMs) * time.Millisecond)
+	return 0
+}
+
+// Upload chunk sizes must be a multiple of this.
+const uploadChunkAlignment = 256 << 10
+
+// GoStorageSetUploadChunkSize sets how much write file v buffers before
+// sending it to GCS, in place of the client's 16 MiB default. chunkSizeBytes
+// must be a positive multiple of 256 KiB, and can only be set before the first
+// write. Returns 0 on success and a negative error code on error.
+//
+//export GoStorageSetUploadChunkSize
+func GoStorageSetUploadChunkSize(v uintptr, chunkSizeBytes C.int) int {
+	slog.Debug("go storage set upload chunk size",
+		"handle", v,
+		"chunk_size_bytes", chunkSizeBytes,
+	)
+	w, _, ok := handle[*writerFile](v)
+	if !ok {
+		slog.Error("set upload chunk size: not a write handle", "v", v)
+		return int(codeBadHandle)
+	}
+	if chunkSizeBytes <= 0 || chunkSizeBytes%uploadChunkAlignment != 0 {
+		slog.Error("set upload chunk size: not a positive multiple of 256 KiB", "chunk_size_bytes", chunkSizeBytes)
+		return int(codeInvalidArgument)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.written {
+		slog.Error("set upload chunk size: already written", "v", v)
+		return int(codeInvalidArgument)
+	}
+	w.w.ChunkSize = int(chunkSizeBytes)
+	w.chunkSize = int(chunkSizeBytes)
+	return 0
+}
+
+// GoStorageGetUploadChunkSize returns the chunk size set on write file v with
+// GoStorageSetUploadChunkSize, 0 if it uses the client's default, or a
+// negative error code on error.
+//
+//export GoStorageGetUploadChunkSize
+func GoStorageGetUploadChunkSize(v uintptr) C.int {
+	slog.Debug("go storage get upload chunk size", "handle", v)
+	w, _, ok := handle[*writerFile](v)
+	if !ok {
+		slog.Error("get upload chunk size: not a write handle", "v", v)
+		return C.int(codeBadHandle)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return C.int(w.chunkSize)
+}
+
+// GoStorageOpenWriteCheckpoint opens a write file that appends to the flushed
+// contents of an existing appendable object, taking over from a writer that
+// failed or was abandoned. If generation is 0, the latest generation is used.
+//
+//export GoStorageOpenWriteCheckpoint
+func GoStorageOpenWriteCheckpoint(td uintptr, flushAfterEveryWrite bool, filenameCstr *C.char, generation int64) uintptr {
+	filename := C.GoString(filenameCstr)
+	slog.Debug("go storage open write checkpoint",
+		"td", td,
+		"filename", filename,
+		"generation", generation,
+	)
+	t, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("open: error getting *storage.ObjectHandle", "err", err)
+		return 0
+	}
+
+	if generation == 0 {
+		attrs, err := oh.Attrs(context.Background())
+		if err != nil {
+			slog.Error("open write checkpoint: failed to get generation",
+				"filename", filename,
+				"err", err,
+			)
+			return 0
+		}
+		generation = attrs.Generation
+	}
+
+	w, offset, err := oh.Generation(generation).
+		Retryer(storage.WithPolicy(storage.RetryAlways)).
+		NewWriterFromAppendableObject(context.Background(), nil)
+	if err != nil {
+		slog.Error("failed appendable object takeover",
+			"filename", filename,
+			"generation", generation,
+			"err", err,
+		)
+		return 0
+	}
+	slog.Info("resuming write from checkpoint",
+		"filename", filename,
+		"generation", generation,
+		"offset", offset,
+	)
+	return newThreadHandle(t, &writerFile{
+		w:                    w,
+		flushAfterEveryWrite: flushAfterEveryWrite,
+	})
+}
+
+// GoStorageOpenWithTTL is like GoStorageOpenWriteonly, without flushing after
+// every write, but marks the object to expire ttlSeconds from now, so that
+// benchmark objects needn't be cleaned up by hand. GCS has no per-object TTL,
+// so the expiry is stored as the object's custom time; a bucket lifecycle rule
+// deleting objects 0 days since their custom time does the expiring. Returns 0
+// on error.
+//
+//export GoStorageOpenWithTTL
+func GoStorageOpenWithTTL(td uintptr, filenameCstr *C.char, ttlSeconds int64) uintptr {
+	filename := C.GoString(filenameCstr)
+	ttl := time.Duration(ttlSeconds) * time.Second
+	slog.Debug("go storage open with ttl",
+		"td", td,
+		"filename", filename,
+		"ttl", ttl,
+	)
+	if ttlSeconds <= 0 {
+		slog.Error("open with ttl: invalid ttl", "ttl_seconds", ttlSeconds)
+		return 0
+	}
+	t, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("open: error getting *storage.ObjectHandle", "err", err)
+		return 0
+	}
+
+	w := oh.Retryer(storage.WithPolicy(storage.RetryAlways)).NewWriter(context.Background())
+	w.Append = true
+	w.CustomTime = time.Now().Add(ttl)
+	return newThreadHandle(t, &writerFile{w: w})
+}
+
+// GoStorageOpenWriteNew is like GoStorageOpenWriteonly, without flushing after
+// every write, but only creates the object if it doesn't exist, so that setup
+// jobs can't silently overwrite existing benchmark data. The object is
+// uploaded as an ordinary, not appendable, object, so GCS checks the
+// condition when the object is committed, and an existing object is reported
+// by GoStorageWriteClose, not here. Since data written to the file isn't
+// visible until then, it can't be flushed with GoStorageSetFlushInterval or
+// GoStorageQueueReadAfterWrite. Returns 0 on error.
+//
+//export GoStorageOpenWriteNew
+func GoStorageOpenWriteNew(td uintptr, filenameCstr *C.char) uintptr {
+	filename := C.GoString(filenameCstr)
+	slog.Debug("go storage open write new",
+		"td", td,
+		"filename", filename,
+	)
+	t, oh, err := filenameObjectHandle(td, filename)
+	if err != nil {
+		slog.Error("open: error getting *storage.ObjectHandle", "err", err)
+		return 0
+	}
+
+	w := oh.If(storage.Conditions{DoesNotExist: true}).
+		Retryer(storage.WithPolicy(storage.RetryAlways)).
+		NewWriter(context.Background())
+	return newThreadHandle(t, &writerFile{w: w})
+}
+
+// GoStorageWriteClose is like GoStorageClose for write file v, but reports
+// whether the object was committed rather than only logging failures. An
+// object opened with GoStorageOpenWriteNew that already existed fails with
+// GO_STORAGE_ERR_PRECONDITION_FAILED and is left unchanged. v is released
+// either way. Returns 0 on success and a negative error code on error.
+//
+//export GoStorageWriteClose
+func GoStorageWriteClose(v uintptr) int {
+	slog.Debug("go storage write close", "handle", v)
+	w, h, ok := handle[*writerFile](v)
+	if !ok {
+		slog.Error("write close: wrong type handle", "v", v)
+		return int(codeBadHandle)
+	}
+	deleteHandle(h)
+	if err := w.Close(); err != nil {
+		slog.Error("write close: failed to commit object", "err", err)
+		return int(errorCodeOf(err))
+	}
+	return 0
+}
+
+// writeFlushed writes p to w and flushes it, so that the data is visible to
+// readers once writeFlushed returns.
+func (w *writerFile) writeFlushed(p []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = true
+	if _, err := w.w.Write(p); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	if _, err := w.w.Flush(); err != nil {
+		return fmt.Errorf("flush: %w", err)
+	}
+	return nil
+}
+
+// GoStorageQueueReadAfterWrite writes wbuf to write file writeHandle, and once
+// the write has been flushed, reads len bytes at offset from read file
+// readHandle into rbuf. iou completes on td when the read finishes, or with
+// the write error if the write fails; the write itself produces no
+// completion. Returns 1 if queued, and a negative error code on error.
+//
+//export GoStorageQueueReadAfterWrite
+func GoStorageQueueReadAfterWrite(td, writeHandle, readHandle uintptr, iou unsafe.Pointer, offset int64, wbuf, rbuf unsafe.Pointer, bufLen C.int) int {
+	slog.Debug("go storage queue read after write",
+		"td", td,
+		"write_handle", writeHandle,
+		"read_handle", readHandle,
+		"offset", offset,
+	)
+	t, _, ok := handle[*threadData](td)
+	if !ok {
+		slog.Error("queue read after write: wrong type handle", "td", td)
+		return int(codeBadHandle)
+	}
+	w, _, ok := handle[*writerFile](writeHandle)
+	if !ok {
+		slog.Error("queue read after write: not a write handle", "v", writeHandle)
+		return int(codeBadHandle)
+	}
+	r, _, ok := handle[goFile](readHandle)
+	if _, isWriter := r.(*writerFile); !ok || isWriter {
+		slog.Error("queue read after write: not a read handle", "v", readHandle)
+		return int(codeBadHandle)
+	}
+	if res := checkQueue("queue read after write", r, offset, rbuf, bufLen); res < 0 {
+		return res
+	}
+
+	wp := C.GoBytes(wbuf, bufLen)
+	rp := C.GoBytes(rbuf, bufLen)
+	return trackInflight(readHandle, iou, offset, int64(bufLen), func() int {
+		go func() {
+			if err := w.writeFlushed(wp); err != nil {
+				slog.Error("read after write: write failed", "err", err)
+				t.complete(iou, err)
+				return
+			}
+			switch res := r.enqueue(rp, offset, iou); {
+			case res < 0:
+				t.complete(iou, fmt.Errorf("read after write: failed to queue read at offset %d", offset))
+			case res == fioQCompleted:
+				// Reads served without queuing still owe the caller a completion.
+				t.complete(iou, nil)
+			}
+		}()
+		return fioQQueued
+	})
+}